@@ -2,16 +2,34 @@ package main
 
 import (
 	"errors"
+	"io/ioutil"
 	"net/url"
+	"strings"
+	"time"
 
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/zalando-incubator/mate/plan"
 )
 
 type mateConfig struct {
+	command  string
+	planFile string
+
 	producer string
 	consumer string
 	debug    bool
 	syncOnly bool
+	dryRun   bool
+
+	policy            string
+	domainFilter      []string
+	excludeDomains    []string
+	maxDeletions      string
+	allowMassDeletion bool
+
+	resolveHostnames bool
+	resolverAddress  string
 
 	fakeDNSName       string
 	fakeMode          string
@@ -25,22 +43,57 @@ type mateConfig struct {
 	kubernetesTrackNodePorts bool
 	kubernetesFilter         map[string]string
 
+	clusterName      string
+	ownershipKey     string
+	ownershipKeyFile string
+
 	awsRecordGroupID string
+	awsZoneIDs       []string
+	awsZoneTags      map[string]string
+	awsZoneType      string
+	awsLBRegions     []string
+	awsRoleARN       string
+	awsExternalID    string
+	awsR53Endpoint   string
+	awsELBEndpoint   string
+	awsSTSEndpoint   string
+	awsRegion        string
+	awsProfile       string
 
-	googleProject       string
-	googleRecordGroupID string
+	googleProject             string
+	googleRecordGroupID       string
+	googleZones               []string
+	googleZoneLabels          map[string]string
+	googleZoneVisibility      string
+	googleZoneRefreshInterval time.Duration
+	googleEndpoint            string
+	googleCredentials         string
 }
 
 func newConfig(version string) *mateConfig {
 	kingpin.Version(version)
-	return &mateConfig{kubernetesFilter: map[string]string{}}
+	return &mateConfig{kubernetesFilter: map[string]string{}, awsZoneTags: map[string]string{}, googleZoneLabels: map[string]string{}}
 }
 
 func (cfg *mateConfig) parseFlags() {
-	kingpin.Flag("producer", "The endpoints producer to use.").Required().StringVar(&cfg.producer)
+	kingpin.Command("run", "Keep the DNS records in sync with the produced endpoints.").Default()
+	kingpin.Command("plan", "Print the changes to DNS records without applying them.").
+		Flag("out", "File to save the plan to, for later use with apply.").StringVar(&cfg.planFile)
+	kingpin.Command("apply", "Apply a plan previously saved with plan --out.").
+		Arg("plan", "The plan file to apply.").Required().ExistingFileVar(&cfg.planFile)
+
+	kingpin.Flag("producer", "The endpoints producer to use.").StringVar(&cfg.producer)
 	kingpin.Flag("consumer", "The endpoints consumer to use.").Required().StringVar(&cfg.consumer)
 	kingpin.Flag("debug", "Enable debug logging.").BoolVar(&cfg.debug)
 	kingpin.Flag("sync-only", "Disable event watcher").BoolVar(&cfg.syncOnly)
+	kingpin.Flag("dry-run", "Log the changes to DNS records instead of applying them.").BoolVar(&cfg.dryRun)
+	kingpin.Flag("resolve-hostnames", "Resolve hostname targets to their IPs and create A records for them.").BoolVar(&cfg.resolveHostnames)
+	kingpin.Flag("resolver-address", "Address of the DNS server to resolve hostnames with, e.g. 10.0.0.2:53. Defaults to the system resolver.").StringVar(&cfg.resolverAddress)
+	kingpin.Flag("policy", "Changes allowed to DNS records: sync, upsert-only or create-only.").Default(string(plan.SyncPolicy)).EnumVar(&cfg.policy, string(plan.SyncPolicy), string(plan.UpsertOnlyPolicy), string(plan.CreateOnlyPolicy))
+	kingpin.Flag("domain-filter", "Only manage DNS names in this domain and its subdomains. Can be repeated.").StringsVar(&cfg.domainFilter)
+	kingpin.Flag("exclude-domains", "Never manage DNS names in this domain and its subdomains. Can be repeated.").StringsVar(&cfg.excludeDomains)
+	kingpin.Flag("max-deletions", "Maximum number (e.g. 10) or percentage (e.g. 25%) of owned records to delete at once.").StringVar(&cfg.maxDeletions)
+	kingpin.Flag("allow-mass-deletion", "Delete records even if exceeding --max-deletions.").BoolVar(&cfg.allowMassDeletion)
 
 	kingpin.Flag("fake-dnsname", "The fake DNS name to use.").StringVar(&cfg.fakeDNSName)
 	kingpin.Flag("fake-mode", "The mode to run in.").StringVar(&cfg.fakeMode)
@@ -54,20 +107,84 @@ func (cfg *mateConfig) parseFlags() {
 	kingpin.Flag("kubernetes-track-node-ports", "When true, generates DNS entries for type=NodePort services").BoolVar(&cfg.kubernetesTrackNodePorts)
 	kingpin.Flag("kubernetes-filter", "A set of annotations that must match in order to process the object.").StringMapVar(&cfg.kubernetesFilter)
 
+	kingpin.Flag("cluster-name", "Name of the cluster, recorded in the ownership records of created DNS records.").StringVar(&cfg.clusterName)
+	kingpin.Flag("ownership-key", "Secret used to sign and verify ownership records.").Envar("MATE_OWNERSHIP_KEY").StringVar(&cfg.ownershipKey)
+	kingpin.Flag("ownership-key-file", "File containing the secret used to sign and verify ownership records.").ExistingFileVar(&cfg.ownershipKeyFile)
+
 	kingpin.Flag("aws-record-group-id", "Identifier to filter mate created records ").StringVar(&cfg.awsRecordGroupID)
+	kingpin.Flag("aws-zone-id", "Only manage the hosted zone with this id. Can be repeated.").StringsVar(&cfg.awsZoneIDs)
+	kingpin.Flag("aws-zone-tag", "Only manage hosted zones with this tag, e.g. team=foo. Can be repeated.").StringMapVar(&cfg.awsZoneTags)
+	kingpin.Flag("aws-lb-region", "Region to look up load balancers in if it can't be inferred from their hostname. Can be repeated.").StringsVar(&cfg.awsLBRegions)
+	kingpin.Flag("aws-route53-role-arn", "Role to assume for managing records in Route53, e.g. in another account.").StringVar(&cfg.awsRoleARN)
+	kingpin.Flag("aws-route53-external-id", "External id required to assume the Route53 role.").StringVar(&cfg.awsExternalID)
+	kingpin.Flag("aws-route53-endpoint", "Endpoint of the Route53 API, e.g. of a VPC endpoint or a local stand-in.").StringVar(&cfg.awsR53Endpoint)
+	kingpin.Flag("aws-elb-endpoint", "Endpoint of the Elastic Load Balancing API, e.g. of a VPC endpoint or a local stand-in.").StringVar(&cfg.awsELBEndpoint)
+	kingpin.Flag("aws-sts-endpoint", "Endpoint of the STS API used to assume the Route53 role, e.g. of a VPC endpoint.").StringVar(&cfg.awsSTSEndpoint)
+	kingpin.Flag("aws-region", "Default AWS region.").StringVar(&cfg.awsRegion)
+	kingpin.Flag("aws-profile", "Profile of the shared AWS configuration to use.").StringVar(&cfg.awsProfile)
+	kingpin.Flag("aws-zone-type", "Only manage hosted zones of this type: public or private.").EnumVar(&cfg.awsZoneType, "public", "private")
 
 	kingpin.Flag("google-project", "Project ID that manages the zone").StringVar(&cfg.googleProject)
 	kingpin.Flag("google-record-group-id", "Name of the zone to manage.").StringVar(&cfg.googleRecordGroupID)
+	kingpin.Flag("google-zone", "Only manage the managed zone with this name. Can be repeated.").StringsVar(&cfg.googleZones)
+	kingpin.Flag("google-zone-label", "Only manage managed zones with this label, e.g. team=foo. Can be repeated.").StringMapVar(&cfg.googleZoneLabels)
+	kingpin.Flag("google-zone-visibility", "Only manage managed zones of this visibility: public or private.").EnumVar(&cfg.googleZoneVisibility, "public", "private")
+	kingpin.Flag("google-zone-refresh-interval", "Interval of listing the managed zones again to pick up new ones.").Default("1m").DurationVar(&cfg.googleZoneRefreshInterval)
+	kingpin.Flag("google-endpoint", "Base path of the Cloud DNS API, e.g. of a local stand-in.").StringVar(&cfg.googleEndpoint)
+	kingpin.Flag("google-credentials-file", "Service account key file to use instead of the default credentials.").ExistingFileVar(&cfg.googleCredentials)
 
-	kingpin.Parse()
+	cfg.command = kingpin.Parse()
 }
 
 func (cfg *mateConfig) validate() error {
+	if cfg.command != "apply" && cfg.producer == "" {
+		return errors.New("Missing producer flag")
+	}
 	if cfg.consumer == "aws" && cfg.awsRecordGroupID == "" {
 		return errors.New("Missing aws record group id flag")
 	}
 	if cfg.consumer == "google" && cfg.googleRecordGroupID == "" {
 		return errors.New("Missing google record group id flag")
 	}
+	if cfg.maxDeletions != "" {
+		if _, err := plan.ParseDeletionLimit(cfg.maxDeletions); err != nil {
+			return err
+		}
+	}
+	if cfg.awsExternalID != "" && cfg.awsRoleARN == "" {
+		return errors.New("External id given without a role to assume for Route53")
+	}
+	if cfg.resolverAddress != "" && !cfg.resolveHostnames {
+		return errors.New("Resolver address given without resolving hostnames")
+	}
+	if cfg.ownershipKey != "" && cfg.ownershipKeyFile != "" {
+		return errors.New("Only one of ownership key and ownership key file can be used")
+	}
 	return nil
 }
+
+// ownershipSecret returns the key used to sign ownership records, or nil if
+// ownership records shouldn't be signed.
+func (cfg *mateConfig) ownershipSecret() ([]byte, error) {
+	if cfg.ownershipKeyFile == "" {
+		return []byte(cfg.ownershipKey), nil
+	}
+
+	key, err := ioutil.ReadFile(cfg.ownershipKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.TrimSpace(string(key))), nil
+}
+
+// deletionLimit returns the maximum of records to delete at once, or nil if
+// deletions shouldn't be limited.
+func (cfg *mateConfig) deletionLimit() *plan.DeletionLimit {
+	if cfg.maxDeletions == "" || cfg.allowMassDeletion {
+		return nil
+	}
+
+	limit, _ := plan.ParseDeletionLimit(cfg.maxDeletions) // validated before
+	return limit
+}
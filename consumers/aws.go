@@ -1,11 +1,13 @@
 package consumers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-
+	"sort"
+	"strconv"
 	"strings"
-
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
@@ -13,34 +15,115 @@ import (
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/zalando-incubator/mate/pkg"
 	awsclient "github.com/zalando-incubator/mate/pkg/aws"
+	"github.com/zalando-incubator/mate/plan"
 )
 
 // AWSClient interface
 type AWSClient interface {
 	ListRecordSets(zoneID string) ([]*route53.ResourceRecordSet, error)
 	ChangeRecordSets(upsert, del, create []*route53.ResourceRecordSet, zoneID string) error
-	GetCanonicalZoneIDs(lbDNS []string) (map[string]string, error) //get hosted zone ids for the LBs
-	GetHostedZones() (map[string]string, error)                    //get all route53 hosted zones for the account
+	GetCanonicalZoneIDs(lbDNS []string) (map[string]string, error)            //get hosted zone ids for the LBs
+	GetHostedZones() (map[string]*awsclient.HostedZone, error)                //get all route53 hosted zones for the account
+	GetHostedZoneTags(zoneIDs []string) (map[string]map[string]string, error) //get the tags of the hosted zones
+	ListHealthChecks() (map[string]*route53.HealthCheck, error)               //get all health checks of the account
+	CreateHealthCheck(reference string, config *route53.HealthCheckConfig) (string, error)
+	DeleteHealthCheck(id string) error
 }
 
 type awsConsumer struct {
-	groupID string
-	client  AWSClient
+	groupID      string
+	cluster      string
+	key          []byte
+	dryRun       bool
+	maxDeletions *plan.DeletionLimit
+	policy       plan.Policy
+	domainFilter pkg.DomainFilter
+	zoneIDs      []string
+	zoneTags     map[string]string
+	zoneType     string
+	client       AWSClient
+}
+
+// AWSOptions configures the AWS Route53 consumer.
+type AWSOptions struct {
+	RecordGroupID string
+	ClusterName   string
+	OwnershipKey  []byte
+	DryRun        bool
+	MaxDeletions  *plan.DeletionLimit
+	Policy        plan.Policy
+	DomainFilter  pkg.DomainFilter
+
+	// Restrict the managed hosted zones to the ones with the given ids, with
+	// all of the given tags and of the given type, "public" or "private".
+	ZoneIDs  []string
+	ZoneTags map[string]string
+	ZoneType string
+
+	// The regions to look up load balancers in whose region can't be
+	// inferred from their hostname.
+	LoadBalancerRegions []string
+
+	// The role to assume for managing records in Route53, along with its
+	// external id.
+	Route53RoleARN    string
+	Route53ExternalID string
+
+	// Override the endpoints of the Route53, Elastic Load Balancing and STS
+	// APIs, the region and the shared configuration profile.
+	Route53Endpoint string
+	ELBEndpoint     string
+	STSEndpoint     string
+	Region          string
+	Profile         string
 }
 
 const (
 	evaluateTargetHealth = true
 	defaultTxtTTL        = int64(300)
 	defaultATTL          = int64(300)
+	defaultCNAMETTL      = int64(300)
+
+	aliasHostedZoneAttribute           = "aws/alias-hosted-zone-id"
+	aliasEvaluateTargetHealthAttribute = "aws/alias-evaluate-target-health"
+	healthCheckIDAttribute             = "aws/health-check-id"
+	healthCheckReferenceAttribute      = "aws/health-check-reference"
+
+	healthCheckReferencePrefix = "mate/"
+	maxHealthCheckGenerations  = 100 //references of deleted health checks can't be reused, so a generation is added
+
+	zoneTypeAnnotation = "zalando.org/aws-zone-type" //selects the type of hosted zone in split-horizon setups
+	publicZoneType     = "public"
+	privateZoneType    = "private"
 )
 
 // NewAWSRoute53Consumer reates a Consumer instance to sync and process DNS
 // entries in AWS Route53.
-func NewAWSRoute53Consumer(awsRecordGroupID string) (Consumer, error) {
-	if awsRecordGroupID == "" {
+func NewAWSRoute53Consumer(opts *AWSOptions) (Consumer, error) {
+	if opts.RecordGroupID == "" {
 		return nil, errors.New("please provide --aws-record-group-id")
 	}
-	return withClient(awsclient.New(awsclient.Options{}), awsRecordGroupID), nil
+	client := awsclient.New(awsclient.Options{
+		Regions:           opts.LoadBalancerRegions,
+		Route53RoleARN:    opts.Route53RoleARN,
+		Route53ExternalID: opts.Route53ExternalID,
+		Route53Endpoint:   opts.Route53Endpoint,
+		ELBEndpoint:       opts.ELBEndpoint,
+		STSEndpoint:       opts.STSEndpoint,
+		Region:            opts.Region,
+		Profile:           opts.Profile,
+	})
+	consumer := withClient(client, opts.RecordGroupID)
+	consumer.cluster = opts.ClusterName
+	consumer.key = opts.OwnershipKey
+	consumer.dryRun = opts.DryRun
+	consumer.maxDeletions = opts.MaxDeletions
+	consumer.policy = opts.Policy
+	consumer.domainFilter = opts.DomainFilter
+	consumer.zoneIDs = opts.ZoneIDs
+	consumer.zoneTags = opts.ZoneTags
+	consumer.zoneType = opts.ZoneType
+	return consumer, nil
 }
 
 func withClient(c AWSClient, groupID string) *awsConsumer {
@@ -51,123 +134,403 @@ func withClient(c AWSClient, groupID string) *awsConsumer {
 }
 
 func (a *awsConsumer) Sync(endpoints []*pkg.Endpoint) error {
-	kubeRecords, err := a.endpointsToRecords(endpoints)
+	p, existingByZoneID, healthChecks, err := a.plan(endpoints)
 	if err != nil {
 		return err
 	}
-
-	hostedZonesMap, err := a.client.GetHostedZones()
-	if err != nil {
-		return err
-	}
-	if len(hostedZonesMap) == 0 {
+	if len(p.Zones) == 0 {
 		log.Warnln("No hosted zones found in Route53. At least one hosted zone should be created to create DNS records...")
 		return nil
 	}
-
-	inputByZoneID := map[string][]*route53.ResourceRecordSet{}
-	for _, record := range kubeRecords {
-		zoneID := getZoneIDForEndpoint(hostedZonesMap, record) //this guarantees that the endpoint will not be created in multiple hosted zones
-		if zoneID == "" {
-			log.Warnf("Hosted zone for endpoint: %s was not found. Skipping record...", aws.StringValue(record.Name))
-			continue
-		}
-		inputByZoneID[zoneID] = append(inputByZoneID[zoneID], record)
+	if err := a.maxDeletions.Check(p); err != nil {
+		return err
 	}
+	a.sweepHealthChecks(existingByZoneID, healthChecks)
 
 	var wg sync.WaitGroup
-	for zoneName, zoneID := range hostedZonesMap {
+	for _, zone := range p.Zones {
 		wg.Add(1)
-		go func(zoneName, zoneID string) {
+		go func(zone *plan.Zone) {
 			defer wg.Done()
-			err := a.syncPerHostedZone(inputByZoneID[zoneID], zoneID)
-			if err != nil {
+			if err := a.applyPerHostedZone(zone); err != nil {
 				//should pass the err down the error channel
 				//for now just log
-				log.Errorf("Error changing records per zone: %s", zoneName)
+				log.Errorf("Error changing records per zone: %s: %v", zone.Name, err)
 			}
-		}(zoneName, zoneID)
+		}(zone)
 	}
 	wg.Wait()
 	return nil
 }
 
-func (a *awsConsumer) syncPerHostedZone(kubeRecords []*route53.ResourceRecordSet, zoneID string) error {
-	existingRecords, err := a.client.ListRecordSets(zoneID)
+//Plan computes the changes for all hosted zones without applying them
+func (a *awsConsumer) Plan(endpoints []*pkg.Endpoint) (*plan.Plan, error) {
+	p, _, _, err := a.plan(endpoints)
+	return p, err
+}
+
+//plan computes the changes for all hosted zones and returns them along with the existing records and health checks
+//they were computed from
+func (a *awsConsumer) plan(endpoints []*pkg.Endpoint) (*plan.Plan, map[string][]*route53.ResourceRecordSet, map[string]*route53.HealthCheck, error) {
+	hostedZones, desiredByZoneID, err := a.desiredRecordsByZone(endpoints)
 	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	zoneIDs := make([]string, 0, len(hostedZones))
+	for zoneID := range hostedZones {
+		zoneIDs = append(zoneIDs, zoneID)
+	}
+	sort.Strings(zoneIDs)
+
+	existingByZoneID := map[string][]*route53.ResourceRecordSet{}
+	for _, zoneID := range zoneIDs {
+		existingRecords, err := a.client.ListRecordSets(zoneID)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to plan changes for zone %s: %v", hostedZones[zoneID].Name, err)
+		}
+		existingByZoneID[zoneID] = existingRecords
+	}
+
+	healthChecks, err := a.healthChecks(desiredByZoneID, existingByZoneID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	p := &plan.Plan{}
+	for _, zoneID := range zoneIDs {
+		p.Zones = append(p.Zones, a.planPerHostedZone(desiredByZoneID[zoneID], existingByZoneID[zoneID], hostedZones[zoneID].Name, zoneID, healthChecks))
+	}
+	return p, existingByZoneID, healthChecks, nil
+}
+
+//Apply applies previously planned changes, provided that none of the hosted zones has changed in the meantime
+func (a *awsConsumer) Apply(p *plan.Plan) error {
+	existingByZoneID := map[string][]*route53.ResourceRecordSet{}
+	for _, zone := range p.Zones {
+		existingRecords, err := a.client.ListRecordSets(zone.ID)
+		if err != nil {
+			return err
+		}
+		existingByZoneID[zone.ID] = existingRecords
+	}
+	healthChecks, err := a.healthChecks(nil, existingByZoneID)
+	if err != nil {
+		return err
+	}
+	for _, zone := range p.Zones {
+		if plan.Fingerprint(a.currentRecords(existingByZoneID[zone.ID], healthChecks)) != zone.Fingerprint {
+			return fmt.Errorf("hosted zone %s has changed since the plan was created", zone.Name)
+		}
+	}
+	if err := a.maxDeletions.Check(p); err != nil {
 		return err
 	}
+	a.sweepHealthChecks(existingByZoneID, healthChecks)
+
+	for _, zone := range p.Zones {
+		if err := a.applyPerHostedZone(zone); err != nil {
+			return fmt.Errorf("failed to apply changes to zone %s: %v", zone.Name, err)
+		}
+	}
+	return nil
+}
+
+//desiredRecordsByZone returns the hosted zones and the records to be created in each of them
+func (a *awsConsumer) desiredRecordsByZone(endpoints []*pkg.Endpoint) (map[string]*awsclient.HostedZone, map[string][]*plan.Record, error) {
+	endpoints = filterEndpoints(endpoints, a.domainFilter)
+
+	kubeRecords, err := a.endpointsToRecords(endpoints)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	recordInfoMap := a.recordInfo(existingRecords)
+	hostedZones, err := a.hostedZones()
+	if err != nil {
+		return nil, nil, err
+	}
 
-	var upsert, del []*route53.ResourceRecordSet
-	upsertedMap := make(map[string]bool) // keep track of records to be upserted
-	targetMap := map[string][]*string{}  // map dnsname -> list of targets
-	for _, kr := range kubeRecords {
-		targetMap[aws.StringValue(kr.Name)] = append(targetMap[aws.StringValue(kr.Name)], aws.String(a.getRecordTarget(kr)))
+	resources := map[string]string{}              // map dnsname -> kubernetes resource
+	zoneTypes := map[string]string{}              // map dnsname -> requested zone type
+	healthChecks := map[string]*pkg.HealthCheck{} // map dnsname -> requested health check
+	for _, ep := range endpoints {
+		resources[pkg.SanitizeDNSName(ep.DNSName)] = ep.Resource
+		zoneTypes[pkg.SanitizeDNSName(ep.DNSName)] = endpointZoneType(ep)
+		healthChecks[pkg.SanitizeDNSName(ep.DNSName)], _ = endpointHealthCheck(ep) //invalid health checks are reported by endpointsToRecords
 	}
-	//find records to be upserted
-	for _, kubeRecord := range kubeRecords {
-		//make sure that another record with same DNS name was not already included into upsert slice
-		if _, upserted := upsertedMap[aws.StringValue(kubeRecord.Name)]; upserted {
+
+	inputByZoneID := map[string][]*route53.ResourceRecordSet{}
+	for _, record := range kubeRecords {
+		zoneID := getZoneIDForEndpoint(hostedZones, record, zoneTypes[aws.StringValue(record.Name)]) //this guarantees that the endpoint will not be created in multiple hosted zones
+		if zoneID == "" {
+			log.Warnf("Hosted zone for endpoint: %s was not found. Skipping record...", aws.StringValue(record.Name))
 			continue
 		}
+		inputByZoneID[zoneID] = append(inputByZoneID[zoneID], record)
+	}
 
-		existingRecordInfo, exist := recordInfoMap[aws.StringValue(kubeRecord.Name)]
+	desiredByZoneID := map[string][]*plan.Record{}
+	for zoneID, records := range inputByZoneID {
+		desiredByZoneID[zoneID] = a.desiredRecords(records, resources, healthChecks)
+	}
+	return hostedZones, desiredByZoneID, nil
+}
 
-		if !exist { //record does not exist, create it
-			newTXTRecord := a.getAssignedTXTRecordObject(kubeRecord)
-			upsert = append(upsert, kubeRecord, newTXTRecord)
-			upsertedMap[aws.StringValue(kubeRecord.Name)] = true
-			continue
+//healthChecks returns the health checks of the account mapped by their id, they are only listed if any of the
+//desired records requests a health check or any of the existing records refers to one
+func (a *awsConsumer) healthChecks(desiredByZoneID map[string][]*plan.Record, existingByZoneID map[string][]*route53.ResourceRecordSet) (map[string]*route53.HealthCheck, error) {
+	for _, desired := range desiredByZoneID {
+		for _, r := range desired {
+			if r.HealthCheck != nil {
+				return a.client.ListHealthChecks()
+			}
 		}
+	}
+	for _, existingRecords := range existingByZoneID {
+		for _, record := range existingRecords {
+			if aws.StringValue(record.HealthCheckId) != "" {
+				return a.client.ListHealthChecks()
+			}
+		}
+	}
+	return nil, nil
+}
 
-		if existingRecordInfo.GroupID != a.getGroupID() { // there exist a record with a different or empty group ID
-			log.Warnf("Skipping record %s: with a group ID: %s", aws.StringValue(kubeRecord.Name), existingRecordInfo.GroupID)
+//sweepHealthChecks deletes the health checks created by this consumer's group which none of the existing records
+//refers to. They are left behind when changing the records fails after their health checks have been created or
+//before the detached ones have been deleted. Health checks are only swept while they are listed, see healthChecks
+func (a *awsConsumer) sweepHealthChecks(existingByZoneID map[string][]*route53.ResourceRecordSet, healthChecks map[string]*route53.HealthCheck) {
+	if a.dryRun || len(healthChecks) == 0 {
+		return
+	}
+	referenced := map[string]bool{}
+	for _, existingRecords := range existingByZoneID {
+		for _, record := range existingRecords {
+			referenced[aws.StringValue(record.HealthCheckId)] = true
+		}
+	}
+
+	ids := make([]string, 0, len(healthChecks))
+	for id := range healthChecks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if referenced[id] || !a.ownsHealthCheck(aws.StringValue(healthChecks[id].CallerReference)) {
 			continue
 		}
+		if err := a.client.DeleteHealthCheck(id); err != nil {
+			log.Warnf("Failed to delete unused health check %s: %v", id, err)
+			continue
+		}
+		log.Infof("Deleted unused health check %s", id)
+	}
+}
 
-		//there exists a record in AWS Route53 with same DNS name and group id, but need to make sure that
-		//the alias load balancer is no longer used
-		kubeTargetsForDNS := targetMap[aws.StringValue(kubeRecord.Name)]
-		targetStillRequired := false
-		for _, targetPtr := range kubeTargetsForDNS {
-			if pkg.SameDNSName(aws.StringValue(targetPtr), existingRecordInfo.Target) {
-				targetStillRequired = true
-				break
-			}
+//planPerHostedZone computes the changes needed to get the hosted zone from the existing to the desired records
+func (a *awsConsumer) planPerHostedZone(desired []*plan.Record, existingRecords []*route53.ResourceRecordSet, zoneName, zoneID string, healthChecks map[string]*route53.HealthCheck) *plan.Zone {
+	current := a.currentRecords(existingRecords, healthChecks)
+	reportInvalidClaims(current, a.groupID, a.key)
+	zone := plan.NewZone(zoneID, zoneName, desired, current, a.owns)
+	if zone.Suppressed = a.policy.Apply(zone.Changes); zone.Suppressed > 0 {
+		log.Infof("Suppressed %d changes in zone %s due to %s policy", zone.Suppressed, zoneName, a.policy)
+	}
+	return zone
+}
+
+func (a *awsConsumer) applyPerHostedZone(zone *plan.Zone) error {
+	changes := zone.Changes
+	if changes.Empty() {
+		log.Infoln("No changes submitted for zone: ", zone.ID)
+		return nil
+	}
+
+	if a.dryRun {
+		log.Infof("[AWS] Dry run, not applying changes to zone %s:\n%s", zone.ID, changes)
+		return nil
+	}
+
+	changes, err := a.createHealthChecks(changes)
+	if err != nil {
+		return err
+	}
+
+	upsert, del := a.changeRecordSets(changes)
+	log.Debugln("Records to be upserted: ", upsert)
+	log.Debugln("Records to be deleted: ", del)
+	if err := a.client.ChangeRecordSets(upsert, del, nil, zone.ID); err != nil {
+		return err
+	}
+
+	a.deleteHealthChecks(changes)
+	return nil
+}
+
+//createHealthChecks creates the health checks of the records to be created or updated and returns the changes
+//with the records referring to them. The references of the health checks are derived from the records, so that
+//retrying a failed change reuses the health checks created before
+func (a *awsConsumer) createHealthChecks(changes *plan.Changes) (*plan.Changes, error) {
+	result := &plan.Changes{Delete: changes.Delete}
+	for _, r := range changes.Create {
+		checked, err := a.createHealthCheck(r)
+		if err != nil {
+			return nil, err
+		}
+		result.Create = append(result.Create, checked)
+	}
+	for _, u := range changes.Update {
+		checked, err := a.createHealthCheck(u.New)
+		if err != nil {
+			return nil, err
 		}
-		if !targetStillRequired { //target is no longer required - overwrite it
-			newTXTRecord := a.getAssignedTXTRecordObject(kubeRecord)
-			upsert = append(upsert, kubeRecord, newTXTRecord)
-			upsertedMap[aws.StringValue(kubeRecord.Name)] = true
+		result.Update = append(result.Update, &plan.Update{Old: u.Old, New: checked})
+	}
+	return result, nil
+}
+
+//createHealthCheck creates the health check of the record if it requests one and returns a copy of the record
+//referring to it
+func (a *awsConsumer) createHealthCheck(r *plan.Record) (*plan.Record, error) {
+	if r.HealthCheck == nil || len(r.Targets) == 0 {
+		return r, nil
+	}
+
+	var reference, id string
+	var err error
+	for generation := 0; generation < maxHealthCheckGenerations; generation++ {
+		reference = a.healthCheckReference(r, generation)
+		id, err = a.client.CreateHealthCheck(reference, healthCheckConfig(r))
+		if err != awsclient.ErrHealthCheckAlreadyExists {
+			break
 		}
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create health check for %s: %v", r.Name, err)
+	}
+	log.Infof("Using health check %s (%s) for %s", id, r.HealthCheck, r.Name)
 
-	//find records to be removed
-	for _, existingRecord := range existingRecords {
-		recordInfo := recordInfoMap[aws.StringValue(existingRecord.Name)]
-		if recordInfo.GroupID == a.getGroupID() {
-			remove := true
-			for _, kubeRecord := range kubeRecords {
-				if pkg.SameDNSName(aws.StringValue(kubeRecord.Name), aws.StringValue(existingRecord.Name)) {
-					remove = false
-				}
-			}
-			if remove {
-				del = append(del, existingRecord)
-			}
+	checked := *r
+	checked.Attributes = map[string]string{healthCheckIDAttribute: id, healthCheckReferenceAttribute: reference}
+	for key, value := range r.Attributes {
+		checked.Attributes[key] = value
+	}
+	return &checked, nil
+}
+
+//deleteHealthChecks deletes the health checks created by this consumer's group which the applied changes have
+//detached from their records. Failures are only logged, as the records have already been changed
+func (a *awsConsumer) deleteHealthChecks(changes *plan.Changes) {
+	detached := changes.Delete
+	for _, u := range changes.Update {
+		if u.Old.Attributes[healthCheckIDAttribute] != u.New.Attributes[healthCheckIDAttribute] {
+			detached = append(detached, u.Old)
+		}
+	}
+
+	for _, r := range detached {
+		id := r.Attributes[healthCheckIDAttribute]
+		if id == "" || !a.ownsHealthCheck(r.Attributes[healthCheckReferenceAttribute]) {
+			continue
+		}
+		if err := a.client.DeleteHealthCheck(id); err != nil {
+			log.Errorf("Failed to delete health check %s of %s: %v", id, r.Name, err)
+			continue
 		}
+		log.Infof("Deleted health check %s of %s", id, r.Name)
 	}
+}
 
-	if len(upsert) > 0 || len(del) > 0 {
-		log.Debugln("Records to be upserted: ", upsert)
-		log.Debugln("Records to be deleted: ", del)
-		return a.client.ChangeRecordSets(upsert, del, nil, zoneID)
+//healthCheckReference returns the caller reference of the health check for the record, derived from the
+//record group, the record and the health check settings. Route53 refuses to reuse the reference of a deleted
+//health check, e.g. when a setting is changed back, so all but the first generation of a reference are suffixed
+func (a *awsConsumer) healthCheckReference(r *plan.Record, generation int) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s %s %s %s %s", a.groupID, r.Name, r.SetIdentifier, strings.Join(r.Targets, ","), r.HealthCheck)))
+	reference := a.healthCheckReferenceGroupPrefix() + hex.EncodeToString(hash[:])[:40]
+	if generation > 0 {
+		reference += fmt.Sprintf("-%d", generation)
 	}
+	return reference
+}
 
-	log.Infoln("No changes submitted for zone: ", zoneID)
-	return nil
+//healthCheckReferenceGroupPrefix returns the prefix of the references of the health checks created by this
+//consumer's group, references are limited to 64 characters so the group id is hashed
+func (a *awsConsumer) healthCheckReferenceGroupPrefix() string {
+	hash := sha256.Sum256([]byte(a.groupID))
+	return healthCheckReferencePrefix + hex.EncodeToString(hash[:])[:8] + "/"
+}
+
+//ownsHealthCheck returns true if the health check with the given reference was created by this consumer's group
+func (a *awsConsumer) ownsHealthCheck(reference string) bool {
+	return strings.HasPrefix(reference, a.healthCheckReferenceGroupPrefix())
+}
+
+//healthCheckConfig returns the route53 health check config checking the target of the record, which is checked
+//by IP for A records and by hostname for Alias and CNAME records
+func healthCheckConfig(r *plan.Record) *route53.HealthCheckConfig {
+	config := &route53.HealthCheckConfig{
+		Type:            aws.String(r.HealthCheck.Type),
+		Port:            aws.Int64(r.HealthCheck.Port),
+		RequestInterval: aws.Int64(r.HealthCheck.Interval),
+	}
+	if r.HealthCheck.Path != "" {
+		config.ResourcePath = aws.String(r.HealthCheck.Path)
+	}
+
+	target := r.Targets[0]
+	if _, isAlias := r.Attributes[aliasHostedZoneAttribute]; isAlias || r.Type == "CNAME" {
+		config.FullyQualifiedDomainName = aws.String(strings.TrimSuffix(target, "."))
+		config.EnableSNI = aws.Bool(r.HealthCheck.Type == pkg.HTTPSHealthCheck)
+	} else {
+		config.IPAddress = aws.String(target)
+	}
+	return config
+}
+
+//recordHealthCheck returns the settings of the route53 health check
+func recordHealthCheck(check *route53.HealthCheck) *pkg.HealthCheck {
+	config := check.HealthCheckConfig
+	return &pkg.HealthCheck{
+		Type:     aws.StringValue(config.Type),
+		Path:     aws.StringValue(config.ResourcePath),
+		Port:     aws.Int64Value(config.Port),
+		Interval: aws.Int64Value(config.RequestInterval),
+	}
+}
+
+//endpointHealthCheck returns the health check requested for the endpoint by its annotations
+func endpointHealthCheck(ep *pkg.Endpoint) (*pkg.HealthCheck, error) {
+	check, err := pkg.ParseHealthCheck(ep.Annotations)
+	if err != nil || check == nil {
+		return nil, err
+	}
+	if check.Interval != 10 && check.Interval != 30 {
+		return nil, fmt.Errorf("invalid health check interval %d, must be 10 or 30 seconds", check.Interval)
+	}
+	return check, nil
+}
+
+//changeRecordSets converts the planned changes into the record sets to be upserted and deleted
+func (a *awsConsumer) changeRecordSets(changes *plan.Changes) (upsert, del []*route53.ResourceRecordSet) {
+	for _, r := range changes.Create {
+		upsert = append(upsert, a.planToRecord(r), a.planToOwnerRecord(r))
+	}
+	for _, u := range changes.Update {
+		if u.Old.Type != "" && u.Old.Type != u.New.Type { //upsert only replaces records of the same type
+			del = append(del, a.planToRecord(u.Old))
+		}
+		ownerRecord := a.planToOwnerRecord(u.New)
+		if u.Old.OwnerRecord != nil && u.Old.OwnerRecord.Name != aws.StringValue(ownerRecord.Name) { //e.g. when replacing an A with a CNAME record
+			del = append(del, a.planToRecord(u.Old.OwnerRecord))
+		}
+		upsert = append(upsert, a.planToRecord(u.New), ownerRecord)
+	}
+	for _, r := range changes.Delete {
+		if r.Type != "" {
+			del = append(del, a.planToRecord(r))
+		}
+		del = append(del, a.planToRecord(r.OwnerRecord))
+	}
+	return upsert, del
 }
 
 func (a *awsConsumer) Consume(endpoints <-chan *pkg.Endpoint, errors chan<- error, done <-chan struct{}, wg *sync.WaitGroup) {
@@ -198,12 +561,23 @@ func (a *awsConsumer) Consume(endpoints <-chan *pkg.Endpoint, errors chan<- erro
 }
 
 func (a *awsConsumer) Process(endpoint *pkg.Endpoint) error {
-	hostedZonesMap, err := a.client.GetHostedZones()
+	return a.processEndpoints([]*pkg.Endpoint{endpoint})
+}
+
+//processEndpoints creates the record of endpoints sharing a dns name, e.g. the IPs of a resolved hostname
+func (a *awsConsumer) processEndpoints(endpoints []*pkg.Endpoint) error {
+	endpoint := endpoints[0]
+	if !a.domainFilter.Match(endpoint.DNSName) {
+		log.Debugf("Skipping endpoint %s: not matched by the domain filter", endpoint.DNSName)
+		return nil
+	}
+
+	hostedZones, err := a.hostedZones()
 	if err != nil {
 		return err
 	}
 
-	ARecords, err := a.endpointsToRecords([]*pkg.Endpoint{endpoint})
+	ARecords, err := a.endpointsToRecords(endpoints)
 	if err != nil {
 		return err
 	}
@@ -211,14 +585,22 @@ func (a *awsConsumer) Process(endpoint *pkg.Endpoint) error {
 		return fmt.Errorf("failed to process endpoint. A record could not be constructed for: %s:%s:%s", endpoint.DNSName, endpoint.Hostname, endpoint.IP)
 	}
 
-	create := []*route53.ResourceRecordSet{ARecords[0], a.getAssignedTXTRecordObject(ARecords[0])}
+	//health checks are attached by the next sync, which would otherwise have to clean up the ones of records
+	//that failed to be created here
+	create := []*route53.ResourceRecordSet{ARecords[0], a.getAssignedTXTRecordObject(ARecords[0], a.owner(a.recordToPlan(ARecords[0]), endpoint.Resource))}
 
-	zoneID := getZoneIDForEndpoint(hostedZonesMap, ARecords[0])
+	zoneID := getZoneIDForEndpoint(hostedZones, ARecords[0], endpointZoneType(endpoint))
 	if zoneID == "" {
 		log.Warnf("Hosted zone for endpoint: %s was not found. Skipping record...", endpoint.DNSName)
 		return nil
 	}
 
+	if a.dryRun {
+		changes := &plan.Changes{Create: a.desiredRecords(ARecords, map[string]string{aws.StringValue(ARecords[0].Name): endpoint.Resource}, nil)}
+		log.Infof("[AWS] Dry run, not applying changes to zone %s:\n%s", zoneID, changes)
+		return nil
+	}
+
 	err = a.client.ChangeRecordSets(nil, nil, create, zoneID)
 	if err != nil && strings.Contains(err.Error(), "already exists") {
 		log.Warnf("Record [name=%s] could not be created, another record with same name already exists", endpoint.DNSName)
@@ -228,90 +610,286 @@ func (a *awsConsumer) Process(endpoint *pkg.Endpoint) error {
 	return err
 }
 
+//hostedZones returns the hosted zones matched by the domain filter and the configured zone ids, tags and type
+func (a *awsConsumer) hostedZones() (map[string]*awsclient.HostedZone, error) {
+	hostedZones, err := a.client.GetHostedZones()
+	if err != nil {
+		return nil, err
+	}
+
+	selected := map[string]*awsclient.HostedZone{}
+	for zoneID, zone := range hostedZones {
+		if a.domainFilter.MatchZone(zone.Name) && a.matchZoneID(zoneID) && matchZoneType(zone, a.zoneType) {
+			selected[zoneID] = zone
+		}
+	}
+
+	if len(a.zoneTags) == 0 || len(selected) == 0 {
+		return selected, nil
+	}
+
+	zoneIDs := make([]string, 0, len(selected))
+	for zoneID := range selected {
+		zoneIDs = append(zoneIDs, zoneID)
+	}
+	tags, err := a.client.GetHostedZoneTags(zoneIDs)
+	if err != nil {
+		return nil, err
+	}
+	for zoneID := range selected {
+		for key, value := range a.zoneTags {
+			if zoneTag, exists := tags[zoneID][key]; !exists || zoneTag != value {
+				delete(selected, zoneID)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+//matchZoneID returns true if no zone ids are configured or the zone id is one of them
+func (a *awsConsumer) matchZoneID(zoneID string) bool {
+	if len(a.zoneIDs) == 0 {
+		return true
+	}
+	for _, id := range a.zoneIDs {
+		if awsclient.CleanZoneID(id) == awsclient.CleanZoneID(zoneID) {
+			return true
+		}
+	}
+	return false
+}
+
+//matchZoneType returns true if the zone is of the given type, any type matches an empty one
+func matchZoneType(zone *awsclient.HostedZone, zoneType string) bool {
+	switch zoneType {
+	case publicZoneType:
+		return !zone.Private
+	case privateZoneType:
+		return zone.Private
+	}
+	return true
+}
+
+//endpointZoneType returns the type of hosted zone requested for the endpoint by its annotation
+func endpointZoneType(ep *pkg.Endpoint) string {
+	zoneType := ep.Annotations[zoneTypeAnnotation]
+	switch zoneType {
+	case "", publicZoneType, privateZoneType:
+		return zoneType
+	}
+	log.Warnf("Ignoring invalid zone type %q of endpoint %s, must be %s or %s", zoneType, ep.DNSName, publicZoneType, privateZoneType)
+	return ""
+}
+
 //getZoneIDForEndpoint returns the zone id for the record based on its dns name, returns best match
 //i.e. if the record has dns name "test.sub.example.com" and route53 has two hosted zones "example.com" and "sub.example.com"
-//"sub.example.com" will be returned
-func getZoneIDForEndpoint(hostedZonesMap map[string]string, record *route53.ResourceRecordSet) string {
-	var matchName string
-	var matchID string
-	for zoneName, zoneID := range hostedZonesMap {
-		if strings.HasSuffix(aws.StringValue(record.Name), zoneName) && len(zoneName) > len(matchName) { //get the longest match for the dns name
-			matchName = zoneName
-			matchID = zoneID
+//"sub.example.com" will be returned. Only zones of the given type are considered unless it's empty, in which case a public
+//zone is preferred over a private zone of the same name
+func getZoneIDForEndpoint(hostedZones map[string]*awsclient.HostedZone, record *route53.ResourceRecordSet, zoneType string) string {
+	var match *awsclient.HostedZone
+	for _, zone := range hostedZones {
+		if !strings.HasSuffix(aws.StringValue(record.Name), zone.Name) || !matchZoneType(zone, zoneType) {
+			continue
 		}
+		if match == nil || len(zone.Name) > len(match.Name) { //get the longest match for the dns name
+			match = zone
+			continue
+		}
+		if len(zone.Name) == len(match.Name) && (match.Private && !zone.Private || match.Private == zone.Private && zone.ID < match.ID) {
+			match = zone
+		}
+	}
+	if match == nil {
+		return ""
 	}
-	return matchID
+	return match.ID
+}
+
+//owner returns the ownership information for a record created for the given kubernetes resource
+func (a *awsConsumer) owner(r *plan.Record, resource string) *pkg.Owner {
+	return newOwner(r, resource, a.groupID, a.cluster, a.key)
 }
 
-//getGroupID returns the idenitifier for AWS records as stored in TXT records
-func (a *awsConsumer) getGroupID() string {
-	return fmt.Sprintf("\"mate:%s\"", a.groupID)
+//isOwner returns true if the ownership information of the record belongs to this consumer's group
+func (a *awsConsumer) isOwner(r *plan.Record) bool {
+	return ownedBy(r, a.groupID, a.key)
 }
 
 //getAssignedTXTRecordObject returns the TXT record which accompanies the Alias record
-func (a *awsConsumer) getAssignedTXTRecordObject(aliasRecord *route53.ResourceRecordSet) *route53.ResourceRecordSet {
+//each ownership label is stored as a separate string to stay within the TXT string length limit
+//the TXT record shares the set identifier and routing policy of the record, so that each member of a
+//policy based record set has its own owner, and its name for all but CNAME records
+func (a *awsConsumer) getAssignedTXTRecordObject(aliasRecord *route53.ResourceRecordSet, owner *pkg.Owner) *route53.ResourceRecordSet {
+	labels := owner.Labels()
+	for i := range labels {
+		labels[i] = strconv.Quote(labels[i])
+	}
 	return &route53.ResourceRecordSet{
-		Type: aws.String("TXT"),
-		Name: aliasRecord.Name,
-		TTL:  aws.Int64(defaultTxtTTL),
+		Type:          aws.String("TXT"),
+		Name:          aws.String(pkg.OwnerRecordName(aws.StringValue(aliasRecord.Name), aws.StringValue(aliasRecord.Type))),
+		TTL:           aws.Int64(defaultTxtTTL),
+		SetIdentifier: aliasRecord.SetIdentifier,
+		Weight:        aliasRecord.Weight,
+		Region:        aliasRecord.Region,
+		GeoLocation:   aliasRecord.GeoLocation,
+		Failover:      aliasRecord.Failover,
 		ResourceRecords: []*route53.ResourceRecord{{
-			Value: aws.String(a.getGroupID()),
+			Value: aws.String(strings.Join(labels, " ")),
 		}},
 	}
 }
 
-//groupIDInfo builds a map from dns name to its group ID
-func (a *awsConsumer) groupIDInfo(records []*route53.ResourceRecordSet) map[string]string {
-	groupIDMap := map[string]string{} //maps dns to group ID
+//owns returns true if the record is owned by this consumer's group. Members of a policy based record set are only
+//owned by the cluster whose set identifier they have, so that clusters sharing a group keep each other's members
+func (a *awsConsumer) owns(r *plan.Record) bool {
+	if r.SetIdentifier != "" && r.SetIdentifier != a.setIdentifier() {
+		return false
+	}
+	return a.domainFilter.Match(r.Name) && a.isOwner(r)
+}
 
+//desiredRecords converts the records to be created in a zone to plan records owned by the kubernetes resources
+func (a *awsConsumer) desiredRecords(records []*route53.ResourceRecordSet, resources map[string]string, healthChecks map[string]*pkg.HealthCheck) []*plan.Record {
+	desired := make([]*plan.Record, 0, len(records))
 	for _, record := range records {
-		if aws.StringValue(record.Type) == "TXT" {
-			if len(record.ResourceRecords) > 0 {
-				groupIDMap[aws.StringValue(record.Name)] = aws.StringValue(record.ResourceRecords[0].Value)
-			} else {
-				log.Errorf("Unexpected response from AWS API, got TXT record with empty resources: %s. Record is excluded from syncing", aws.StringValue(record.Name))
-				groupIDMap[aws.StringValue(record.Name)] = ""
-			}
-		} else {
-			if _, exist := groupIDMap[aws.StringValue(record.Name)]; !exist {
-				groupIDMap[aws.StringValue(record.Name)] = ""
-			}
+		r := a.recordToPlan(record)
+		r.Owner = a.owner(r, resources[r.Name])
+		r.HealthCheck = healthChecks[r.Name]
+		if r.HealthCheck != nil && len(r.Targets) > 1 { //a health check checks a single IP
+			log.Errorf("Not creating a health check for %s, it's not supported for records with several IPs: %v", r.Name, r.Targets)
+			r.HealthCheck = nil
 		}
+		desired = append(desired, r)
 	}
-	return groupIDMap
+	return desired
 }
 
-//recordInfo returns the map of record assigned dns to its target and groupID (can be empty string)
-func (a *awsConsumer) recordInfo(records []*route53.ResourceRecordSet) map[string]*pkg.RecordInfo {
-	groupIDMap := a.groupIDInfo(records)
-	infoMap := map[string]*pkg.RecordInfo{} //maps record DNS to its GroupID (if exists) and Target (LB)
+//currentRecords converts the existing records of a zone to plan records, one per dns name and set identifier,
+//along with their ownership information taken from the TXT records of the same name and set identifier and the
+//settings of their health checks
+func (a *awsConsumer) currentRecords(records []*route53.ResourceRecordSet, healthChecks map[string]*route53.HealthCheck) []*plan.Record {
+	var keys []string
+	current := map[string]*plan.Record{} //maps dns and set identifier to the record
 	for _, record := range records {
-		groupID := groupIDMap[aws.StringValue(record.Name)]
-		if _, exist := infoMap[aws.StringValue(record.Name)]; !exist {
-			infoMap[aws.StringValue(record.Name)] = &pkg.RecordInfo{
-				GroupID: groupID,
-			}
+		name := aws.StringValue(record.Name)
+		if aws.StringValue(record.Type) == "TXT" {
+			name = pkg.OwnedRecordName(name)
+		}
+		key := name + " " + aws.StringValue(record.SetIdentifier)
+		r, exist := current[key]
+		if !exist {
+			r = &plan.Record{Name: name, SetIdentifier: aws.StringValue(record.SetIdentifier)}
+			current[key] = r
+			keys = append(keys, key)
 		}
+
 		if aws.StringValue(record.Type) != "TXT" {
-			infoMap[aws.StringValue(record.Name)].Target = a.getRecordTarget(record) //sanitization not needed here, as per IP case
+			if r.Type == "" {
+				owner, ownerRecord := r.Owner, r.OwnerRecord
+				r = a.recordToPlan(record)
+				r.Owner, r.OwnerRecord = owner, ownerRecord
+				if check, exists := healthChecks[aws.StringValue(record.HealthCheckId)]; exists {
+					r.HealthCheck = recordHealthCheck(check)
+					r.Attributes[healthCheckReferenceAttribute] = aws.StringValue(check.CallerReference)
+				}
+				current[key] = r
+			}
+			continue
 		}
+
+		if len(record.ResourceRecords) == 0 {
+			log.Errorf("Unexpected response from AWS API, got TXT record with empty resources: %s. Record is excluded from syncing", name)
+			continue
+		}
+		r.OwnerRecord = a.recordToPlan(record)
+		r.Owner = pkg.ParseOwner(r.OwnerRecord.Targets...)
 	}
 
-	return infoMap
+	result := make([]*plan.Record, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, current[key])
+	}
+	return result
 }
 
-//getRecordTarget returns the ELB dns for the given record
-func (a *awsConsumer) getRecordTarget(r *route53.ResourceRecordSet) string {
-	if aws.StringValue(r.Type) == "TXT" {
-		return ""
+//recordToPlan converts a route53 record set to a plan record
+func (a *awsConsumer) recordToPlan(rs *route53.ResourceRecordSet) *plan.Record {
+	r := &plan.Record{
+		Name:    aws.StringValue(rs.Name),
+		Type:    aws.StringValue(rs.Type),
+		TTL:     aws.Int64Value(rs.TTL),
+		Targets: a.getRecordTargets(rs),
+
+		SetIdentifier: aws.StringValue(rs.SetIdentifier),
+		Routing:       getRoutingPolicy(rs),
+	}
+	if rs.AliasTarget != nil {
+		r.Attributes = map[string]string{
+			aliasHostedZoneAttribute:           aws.StringValue(rs.AliasTarget.HostedZoneId),
+			aliasEvaluateTargetHealthAttribute: strconv.FormatBool(aws.BoolValue(rs.AliasTarget.EvaluateTargetHealth)),
+		}
+	}
+	if rs.HealthCheckId != nil {
+		if r.Attributes == nil {
+			r.Attributes = map[string]string{}
+		}
+		r.Attributes[healthCheckIDAttribute] = aws.StringValue(rs.HealthCheckId)
+	}
+	return r
+}
+
+//planToRecord converts a plan record back to a route53 record set
+func (a *awsConsumer) planToRecord(r *plan.Record) *route53.ResourceRecordSet {
+	rs := &route53.ResourceRecordSet{
+		Name: aws.String(r.Name),
+		Type: aws.String(r.Type),
+	}
+	if r.Routing != nil {
+		setRoutingPolicy(rs, r.SetIdentifier, r.Routing)
+	}
+	if id, exists := r.Attributes[healthCheckIDAttribute]; exists {
+		rs.HealthCheckId = aws.String(id)
+	}
+	if zoneID, isAlias := r.Attributes[aliasHostedZoneAttribute]; isAlias {
+		evaluate, _ := strconv.ParseBool(r.Attributes[aliasEvaluateTargetHealthAttribute])
+		rs.AliasTarget = &route53.AliasTarget{
+			DNSName:              aws.String(r.Targets[0]),
+			EvaluateTargetHealth: aws.Bool(evaluate),
+			HostedZoneId:         aws.String(zoneID),
+		}
+		return rs
+	}
+	rs.TTL = aws.Int64(r.TTL)
+	for _, target := range r.Targets {
+		rs.ResourceRecords = append(rs.ResourceRecords, &route53.ResourceRecord{Value: aws.String(target)})
 	}
+	return rs
+}
+
+//planToOwnerRecord returns the TXT record holding the ownership information of a desired plan record
+func (a *awsConsumer) planToOwnerRecord(r *plan.Record) *route53.ResourceRecordSet {
+	rs := &route53.ResourceRecordSet{Name: aws.String(r.Name), Type: aws.String(r.Type)}
+	if r.Routing != nil {
+		setRoutingPolicy(rs, r.SetIdentifier, r.Routing)
+	}
+	return a.getAssignedTXTRecordObject(rs, r.Owner)
+}
+
+//getRecordTargets returns the ELB dns or the values of the given record
+func (a *awsConsumer) getRecordTargets(r *route53.ResourceRecordSet) []string {
 	if r.AliasTarget != nil {
-		return aws.StringValue(r.AliasTarget.DNSName)
+		return []string{aws.StringValue(r.AliasTarget.DNSName)}
+	}
+	targets := make([]string, 0, len(r.ResourceRecords))
+	for _, rr := range r.ResourceRecords {
+		targets = append(targets, aws.StringValue(rr.Value))
 	}
-	return aws.StringValue(r.ResourceRecords[0].Value)
+	return targets
 }
 
-//endpointsToRecords converts pkg Endpoint to route53 A [Alias] Records depending whether IP/LB Hostname is used
+//endpointsToRecords converts pkg Endpoint to route53 A [Alias] Records depending whether IP/LB Hostname is used,
+//hostnames without a known canonical zone id are converted to CNAME records
 func (a *awsConsumer) endpointsToRecords(endpoints []*pkg.Endpoint) ([]*route53.ResourceRecordSet, error) {
 	lbDNS := make([]string, 0, len(endpoints))
 	for _, endpoint := range endpoints {
@@ -326,28 +904,141 @@ func (a *awsConsumer) endpointsToRecords(endpoints []*pkg.Endpoint) ([]*route53.
 	var rset []*route53.ResourceRecordSet
 
 	for _, ep := range endpoints {
+		routing, err := pkg.ParseRoutingPolicy(ep.Annotations)
+		if err != nil {
+			log.Errorf("Skipping endpoint %s with invalid routing policy: %v", ep.DNSName, err)
+			continue
+		}
+		if _, err := endpointHealthCheck(ep); err != nil {
+			log.Errorf("Skipping endpoint %s with invalid health check: %v", ep.DNSName, err)
+			continue
+		}
+
+		var record *route53.ResourceRecordSet
 		if loadBalancerZoneID, exist := zoneIDs[ep.Hostname]; exist {
-			rset = append(rset, a.endpointToRecord(ep, aws.String(loadBalancerZoneID)))
+			record = a.endpointToRecord(ep, aws.String(loadBalancerZoneID))
 		} else if ep.IP != "" {
-			rset = append(rset, a.endpointToRecord(ep, aws.String("")))
+			record = a.endpointToRecord(ep, nil)
+		} else if ep.Hostname != "" {
+			if awsclient.IsAWSHostname(ep.Hostname) {
+				log.Warnf("Canonical Zone ID for AWS hostname: %s was not found, creating CNAME record", ep.Hostname)
+			} else {
+				log.Debugf("Canonical Zone ID for endpoint: %s was not found, creating CNAME record", ep.Hostname)
+			}
+			record = a.endpointToCNAMERecord(ep)
 		} else {
-			log.Errorf("Canonical Zone ID for endpoint: %s was not found", ep.Hostname)
+			continue
+		}
+		if routing != nil {
+			setRoutingPolicy(record, a.setIdentifier(), routing)
 		}
+		rset = append(rset, record)
 	}
-	return rset, nil
+	return mergeRecords(rset), nil
+}
+
+//mergeRecords merges A records of the same name and set identifier pointing to IPs into one record with all of
+//their IPs, e.g. the records of a NodePort service which are created for every node. Other records of the same
+//name are kept and compete for it
+func mergeRecords(records []*route53.ResourceRecordSet) []*route53.ResourceRecordSet {
+	merged := make([]*route53.ResourceRecordSet, 0, len(records))
+	byName := map[string]*route53.ResourceRecordSet{} //maps dns and set identifier to the first A record with IPs
+	for _, record := range records {
+		if aws.StringValue(record.Type) != "A" || record.AliasTarget != nil {
+			merged = append(merged, record)
+			continue
+		}
+
+		key := aws.StringValue(record.Name) + " " + aws.StringValue(record.SetIdentifier)
+		first, exists := byName[key]
+		if !exists {
+			byName[key] = record
+			merged = append(merged, record)
+			continue
+		}
+		for _, rr := range record.ResourceRecords {
+			if !hasResourceRecord(first, aws.StringValue(rr.Value)) {
+				first.ResourceRecords = append(first.ResourceRecords, rr)
+			}
+		}
+	}
+	return merged
+}
+
+//hasResourceRecord returns true if the record contains the value
+func hasResourceRecord(record *route53.ResourceRecordSet, value string) bool {
+	for _, rr := range record.ResourceRecords {
+		if aws.StringValue(rr.Value) == value {
+			return true
+		}
+	}
+	return false
+}
+
+//setIdentifier returns the identifier of the records with a routing policy created by this consumer, which
+//allows every cluster to hold its own member of a policy based record set
+func (a *awsConsumer) setIdentifier() string {
+	if a.cluster != "" {
+		return a.cluster
+	}
+	return a.groupID
+}
+
+//setRoutingPolicy sets the set identifier and the routing policy of the record
+func setRoutingPolicy(rs *route53.ResourceRecordSet, setIdentifier string, routing *pkg.RoutingPolicy) {
+	rs.SetIdentifier = aws.String(setIdentifier)
+	switch routing.Type {
+	case pkg.WeightedRouting:
+		rs.Weight = aws.Int64(routing.Weight)
+	case pkg.LatencyRouting:
+		rs.Region = aws.String(routing.Region)
+	case pkg.GeolocationRouting:
+		rs.GeoLocation = &route53.GeoLocation{}
+		if routing.Continent != "" {
+			rs.GeoLocation.ContinentCode = aws.String(routing.Continent)
+		}
+		if routing.Country != "" {
+			rs.GeoLocation.CountryCode = aws.String(routing.Country)
+		}
+		if routing.Subdivision != "" {
+			rs.GeoLocation.SubdivisionCode = aws.String(routing.Subdivision)
+		}
+	case pkg.FailoverRouting:
+		rs.Failover = aws.String(strings.ToUpper(routing.Failover))
+	}
+}
+
+//getRoutingPolicy returns the routing policy of the record, nil for simple records
+func getRoutingPolicy(rs *route53.ResourceRecordSet) *pkg.RoutingPolicy {
+	switch {
+	case rs.Weight != nil:
+		return &pkg.RoutingPolicy{Type: pkg.WeightedRouting, Weight: aws.Int64Value(rs.Weight)}
+	case rs.Region != nil:
+		return &pkg.RoutingPolicy{Type: pkg.LatencyRouting, Region: aws.StringValue(rs.Region)}
+	case rs.GeoLocation != nil:
+		return &pkg.RoutingPolicy{
+			Type:        pkg.GeolocationRouting,
+			Continent:   aws.StringValue(rs.GeoLocation.ContinentCode),
+			Country:     aws.StringValue(rs.GeoLocation.CountryCode),
+			Subdivision: aws.StringValue(rs.GeoLocation.SubdivisionCode),
+		}
+	case rs.Failover != nil:
+		return &pkg.RoutingPolicy{Type: pkg.FailoverRouting, Failover: strings.ToLower(aws.StringValue(rs.Failover))}
+	}
+	return nil
 }
 
 //endpointToRecord convert endpoint to an AWS A [Alias] record depending whether IP of LB hostname is used
-//if both are specified hostname takes precedence and Alias record is to be created
+//if the canonical zone id of the hostname is known an Alias record is created, otherwise the IP is used
 func (a *awsConsumer) endpointToRecord(ep *pkg.Endpoint, canonicalZoneID *string) *route53.ResourceRecordSet {
 	rs := &route53.ResourceRecordSet{
 		Type: aws.String("A"),
 		Name: aws.String(pkg.SanitizeDNSName(ep.DNSName)),
 	}
-	if ep.Hostname != "" {
+	if ep.Hostname != "" && canonicalZoneID != nil {
 		rs.AliasTarget = &route53.AliasTarget{
 			DNSName:              aws.String(pkg.SanitizeDNSName(ep.Hostname)),
-			EvaluateTargetHealth: aws.Bool(evaluateTargetHealth),
+			EvaluateTargetHealth: aws.Bool(evaluateTargetHealth && awsclient.SupportsTargetHealth(ep.Hostname)),
 			HostedZoneId:         canonicalZoneID,
 		}
 	} else {
@@ -360,3 +1051,15 @@ func (a *awsConsumer) endpointToRecord(ep *pkg.Endpoint, canonicalZoneID *string
 	}
 	return rs
 }
+
+//endpointToCNAMERecord converts an endpoint with a hostname that can't be used as alias target to a CNAME record
+func (a *awsConsumer) endpointToCNAMERecord(ep *pkg.Endpoint) *route53.ResourceRecordSet {
+	return &route53.ResourceRecordSet{
+		Type: aws.String("CNAME"),
+		Name: aws.String(pkg.SanitizeDNSName(ep.DNSName)),
+		TTL:  aws.Int64(defaultCNAMETTL),
+		ResourceRecords: []*route53.ResourceRecord{{
+			Value: aws.String(pkg.SanitizeDNSName(ep.Hostname)),
+		}},
+	}
+}
@@ -1,13 +1,20 @@
 package consumers
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/zalando-incubator/mate/pkg"
+	awsclient "github.com/zalando-incubator/mate/pkg/aws"
 	awstest "github.com/zalando-incubator/mate/pkg/aws/test"
+	"github.com/zalando-incubator/mate/plan"
 )
 
 type awsTestItem struct {
@@ -74,52 +81,49 @@ func TestGetAssignedTXTRecordObject(t *testing.T) {
 		Hostname: "amazon.elb.com",
 	}
 	rsA := client.endpointToRecord(ep, &zoneID)
-	rsTXT := client.getAssignedTXTRecordObject(rsA)
+	owner := &pkg.Owner{GroupID: groupID, Resource: "service/default/foo", Cluster: "bar"}
+	rsTXT := client.getAssignedTXTRecordObject(rsA, owner)
 	if *rsTXT.Type != "TXT" ||
 		*rsTXT.Name != "example.com." ||
 		len(rsTXT.ResourceRecords) != 1 ||
-		*rsTXT.ResourceRecords[0].Value != "\"mate:test\"" {
+		*rsTXT.ResourceRecords[0].Value != `"heritage=mate" "mate/record-group-id=test" "mate/resource=service/default/foo" "mate/cluster=bar"` {
 		t.Error("Should create a TXT record")
 	}
+	if parsed := pkg.ParseOwner(*rsTXT.ResourceRecords[0].Value); parsed == nil || !reflect.DeepEqual(parsed, owner) {
+		t.Errorf("TXT record should be parsed back into %v, got %v", owner, parsed)
+	}
 }
 
 func TestGetZoneIDForEndpoint(t *testing.T) {
-	hostedZonesMap := map[string]string{
-		"example.com":                    "id1",
-		"test.com":                       "id2",
-		"sub.test.com":                   "id3",
-		"long-sub1.internal.example.com": "id4",
-		"long-sub2.internal.example.com": "id5",
-	}
-	record1 := &route53.ResourceRecordSet{
-		Name: aws.String("name.example.com"),
-	}
-	record2 := &route53.ResourceRecordSet{
-		Name: aws.String("name.example.test.com"),
-	}
-	record3 := &route53.ResourceRecordSet{
-		Name: aws.String("name.sub.test.com"),
-	}
-	record4 := &route53.ResourceRecordSet{
-		Name: aws.String("name.long-sub1.internal.example.com"),
-	}
-	record5 := &route53.ResourceRecordSet{
-		Name: aws.String("name.long-sub2.internal.example.com"),
+	hostedZones := map[string]*awsclient.HostedZone{
+		"id1": {ID: "id1", Name: "example.com"},
+		"id2": {ID: "id2", Name: "test.com"},
+		"id3": {ID: "id3", Name: "sub.test.com"},
+		"id4": {ID: "id4", Name: "long-sub1.internal.example.com"},
+		"id5": {ID: "id5", Name: "long-sub2.internal.example.com"},
+		"id6": {ID: "id6", Name: "example.com", Private: true},
+		"id7": {ID: "id7", Name: "private.example.com", Private: true},
 	}
-	if getZoneIDForEndpoint(hostedZonesMap, record1) != "id1" {
-		t.Errorf("Incorrect zone id for %v", record1)
-	}
-	if getZoneIDForEndpoint(hostedZonesMap, record2) != "id2" {
-		t.Errorf("Incorrect zone id for %v", record2)
-	}
-	if getZoneIDForEndpoint(hostedZonesMap, record3) != "id3" {
-		t.Errorf("Incorrect zone id for %v", record3)
-	}
-	if getZoneIDForEndpoint(hostedZonesMap, record4) != "id4" {
-		t.Errorf("Incorrect zone id for %v", record4)
-	}
-	if getZoneIDForEndpoint(hostedZonesMap, record5) != "id5" {
-		t.Errorf("Incorrect zone id for %v", record5)
+	for _, test := range []struct {
+		name     string
+		zoneType string
+		zoneID   string
+	}{
+		{"name.example.com", "", "id1"},
+		{"name.example.test.com", "", "id2"},
+		{"name.sub.test.com", "", "id3"},
+		{"name.long-sub1.internal.example.com", "", "id4"},
+		{"name.long-sub2.internal.example.com", "", "id5"},
+		{"name.example.com", "private", "id6"},
+		{"name.example.com", "public", "id1"},
+		{"name.private.example.com", "", "id7"},
+		{"name.private.example.com", "public", "id1"},
+		{"name.test.com", "private", ""},
+	} {
+		record := &route53.ResourceRecordSet{Name: aws.String(test.name)}
+		if zoneID := getZoneIDForEndpoint(hostedZones, record, test.zoneType); zoneID != test.zoneID {
+			t.Errorf("Incorrect zone id for %s (zone type %q): %q, want %q", test.name, test.zoneType, zoneID, test.zoneID)
+		}
 	}
 }
 
@@ -127,86 +131,24 @@ func sameTargets(lb1, lb2 string) bool {
 	return lb1 == lb2
 }
 
-func TestGroupIDInfo(t *testing.T) {
-	groupID := "test"
-	client := &awsConsumer{
-		groupID: groupID,
-	}
-	records := []*route53.ResourceRecordSet{
-		&route53.ResourceRecordSet{
-			Type: aws.String("A"),
-			Name: aws.String("test.example.com."),
-			AliasTarget: &route53.AliasTarget{
-				DNSName:      aws.String("abc.def.ghi."),
-				HostedZoneId: aws.String("123"),
-			},
-		},
-		&route53.ResourceRecordSet{
-			Type: aws.String("TXT"),
-			Name: aws.String("test.example.com."),
-			ResourceRecords: []*route53.ResourceRecord{
-				&route53.ResourceRecord{
-					Value: aws.String(client.getGroupID()),
-				},
-			},
-		},
-		&route53.ResourceRecordSet{
-			Type:            aws.String("TXT"),
-			Name:            aws.String("unusual-1.example.com."),
-			ResourceRecords: nil,
-		},
-		&route53.ResourceRecordSet{
-			Type:            aws.String("TXT"),
-			Name:            aws.String("unusual-2.example.com."),
-			ResourceRecords: []*route53.ResourceRecord{},
-		},
-	}
-	groupIDInfoMap := client.groupIDInfo(records)
-	if len(groupIDInfoMap) != 3 {
-		t.Errorf("Incorrect record info for %v", records)
+func recordsByName(records []*plan.Record) map[string]*plan.Record {
+	result := map[string]*plan.Record{}
+	for _, r := range records {
+		result[r.Name] = r
 	}
-	if val, exist := groupIDInfoMap["test.example.com."]; !exist {
-		t.Errorf("Incorrect record info for %v", records)
-	} else {
-		if val != client.getGroupID() {
-			t.Errorf("Incorrect record info for %v", records)
-		}
-	}
-	records = []*route53.ResourceRecordSet{
-		&route53.ResourceRecordSet{
-			Type: aws.String("A"),
-			Name: aws.String("test.example.com."),
-			ResourceRecords: []*route53.ResourceRecord{
-				&route53.ResourceRecord{
-					Value: aws.String("54.32.12.32"),
-				},
-			},
-		},
-		&route53.ResourceRecordSet{
-			Type: aws.String("TXT"),
-			Name: aws.String("test.example.com."),
-			ResourceRecords: []*route53.ResourceRecord{
-				&route53.ResourceRecord{
-					Value: aws.String(client.getGroupID()),
-				},
-			},
-		},
-	}
-	groupIDInfoMap = client.groupIDInfo(records)
-	if len(groupIDInfoMap) != 1 {
-		t.Errorf("Incorrect record info for %v", records)
-	}
-	if val, exist := groupIDInfoMap["test.example.com."]; !exist {
-		t.Errorf("Incorrect record info for %v", records)
-	} else {
-		if val != client.getGroupID() {
-			t.Errorf("Incorrect record info for %v", records)
-		}
+	return result
+}
+
+func targetOf(r *plan.Record) string {
+	if r.Type == "" || len(r.Targets) == 0 {
+		return ""
 	}
+	return r.Targets[0]
 }
 
-func TestRecordInfo(t *testing.T) {
+func TestCurrentRecords(t *testing.T) {
 	groupID := "test"
+	ownership := `"heritage=mate" "mate/record-group-id=test"`
 	client := &awsConsumer{
 		groupID: groupID,
 	}
@@ -224,7 +166,7 @@ func TestRecordInfo(t *testing.T) {
 			Name: aws.String("test.example.com."),
 			ResourceRecords: []*route53.ResourceRecord{
 				&route53.ResourceRecord{
-					Value: aws.String(client.getGroupID()),
+					Value: aws.String(ownership),
 				},
 			},
 		},
@@ -239,17 +181,17 @@ func TestRecordInfo(t *testing.T) {
 			ResourceRecords: []*route53.ResourceRecord{},
 		},
 	}
-	recordInfoMap := client.recordInfo(records)
+	recordInfoMap := recordsByName(client.currentRecords(records, nil))
 	if len(recordInfoMap) != 3 {
 		t.Errorf("Incorrect record info for %v", records)
 	}
 	if val, exist := recordInfoMap["test.example.com."]; !exist {
 		t.Errorf("Incorrect record info for %v", records)
 	} else {
-		if val.GroupID != client.getGroupID() {
+		if !client.owns(val) {
 			t.Errorf("Incorrect record info for %v", records)
 		}
-		if !sameTargets("abc.def.ghi.", val.Target) {
+		if !sameTargets("abc.def.ghi.", targetOf(val)) {
 			t.Errorf("Incorrect record info for %v", records)
 		}
 	}
@@ -268,22 +210,22 @@ func TestRecordInfo(t *testing.T) {
 			Name: aws.String("test.example.com."),
 			ResourceRecords: []*route53.ResourceRecord{
 				&route53.ResourceRecord{
-					Value: aws.String(client.getGroupID()),
+					Value: aws.String(ownership),
 				},
 			},
 		},
 	}
-	recordInfoMap = client.recordInfo(records)
+	recordInfoMap = recordsByName(client.currentRecords(records, nil))
 	if len(recordInfoMap) != 1 {
 		t.Errorf("Incorrect record info for %v", records)
 	}
 	if val, exist := recordInfoMap["test.example.com."]; !exist {
 		t.Errorf("Incorrect record info for %v", records)
 	} else {
-		if val.GroupID != client.getGroupID() {
+		if !client.owns(val) {
 			t.Errorf("Incorrect record info for %v", records)
 		}
-		if !sameTargets("54.32.12.32", val.Target) {
+		if !sameTargets("54.32.12.32", targetOf(val)) {
 			t.Errorf("Incorrect record target for %v", records)
 		}
 	}
@@ -293,22 +235,22 @@ func TestRecordInfo(t *testing.T) {
 			Name: aws.String("test.example.com."),
 			ResourceRecords: []*route53.ResourceRecord{
 				&route53.ResourceRecord{
-					Value: aws.String(client.getGroupID()),
+					Value: aws.String(ownership),
 				},
 			},
 		},
 	}
-	recordInfoMap = client.recordInfo(records)
+	recordInfoMap = recordsByName(client.currentRecords(records, nil))
 	if len(recordInfoMap) != 1 {
 		t.Errorf("Incorrect record info for %v", records)
 	}
 	if val, exist := recordInfoMap["test.example.com."]; !exist {
 		t.Errorf("Incorrect record info for %v", records)
 	} else {
-		if val.GroupID != client.getGroupID() {
+		if !client.owns(val) {
 			t.Errorf("Incorrect record info for %v", records)
 		}
-		if !sameTargets("", val.Target) {
+		if !sameTargets("", targetOf(val)) {
 			t.Errorf("Incorrect record info for %v", records)
 		}
 	}
@@ -344,48 +286,136 @@ func TestRecordInfo(t *testing.T) {
 			Name: aws.String("test.example.com."),
 			ResourceRecords: []*route53.ResourceRecord{
 				&route53.ResourceRecord{
-					Value: aws.String(client.getGroupID()),
+					Value: aws.String(ownership),
 				},
 			},
 		},
 	}
-	recordInfoMap = client.recordInfo(records)
+	recordInfoMap = recordsByName(client.currentRecords(records, nil))
 	if len(recordInfoMap) != 2 {
 		t.Errorf("Incorrect record info for %v", records)
 	}
 	if val, exist := recordInfoMap["test.example.com."]; !exist {
 		t.Errorf("Incorrect record info for %v", records)
 	} else {
-		if val.GroupID != client.getGroupID() {
+		if !client.owns(val) {
 			t.Errorf("Incorrect record info for %v", records)
 		}
-		if !sameTargets("abc.def.ghi.", val.Target) {
+		if !sameTargets("abc.def.ghi.", targetOf(val)) {
 			t.Errorf("Incorrect record info for %v", records)
 		}
 	}
 	if val, exist := recordInfoMap["new.example.com."]; !exist {
 		t.Errorf("Incorrect record info for %v", records)
 	} else {
-		if val.GroupID != "mate:new-group-id" {
-			t.Errorf("Incorrect record info for %v", records)
+		if val.Owner == nil || val.Owner.GroupID != "new-group-id" || client.isOwner(val) {
+			t.Errorf("Incorrect record owner for %v", records)
 		}
-		if !sameTargets("elb.com.", val.Target) {
+		if !sameTargets("elb.com.", targetOf(val)) {
 			t.Errorf("Incorrect record info for %v", records)
 		}
 	}
 }
 
-func TestGetGroupID(t *testing.T) {
-	groupID := "test"
+func TestIsOwnerWithKey(t *testing.T) {
+	key := []byte("secret")
 	client := &awsConsumer{
-		groupID: groupID,
+		groupID: "test",
+		key:     key,
 	}
-	if client.getGroupID() != "\"mate:test\"" {
-		t.Errorf("Should return TXT value of \"mate:test\", when test is passed")
+	signed := client.owner(&plan.Record{Name: "test.example.com.", Type: "A", SetIdentifier: "cluster-a"}, "service/default/foo")
+	rsTXT := client.getAssignedTXTRecordObject(&route53.ResourceRecordSet{Name: aws.String("test.example.com."), Type: aws.String("A")}, signed)
+	value := *rsTXT.ResourceRecords[0].Value
+
+	forged := *pkg.ParseOwner(value)
+	forged.Resource = "service/default/bar"
+
+	copied := pkg.ParseOwner(value)
+	copied.Sign("test.example.com.", "A", "cluster-a", []byte("guessed"))
+
+	for _, test := range []struct {
+		name, recordType, setIdentifier string
+		owner                           *pkg.Owner
+		owned                           bool
+	}{
+		{"test.example.com.", "A", "cluster-a", pkg.ParseOwner(value), true},
+		{"other.example.com.", "A", "cluster-a", pkg.ParseOwner(value), false},
+		{"test.example.com.", "CNAME", "cluster-a", pkg.ParseOwner(value), false},
+		{"test.example.com.", "A", "cluster-b", pkg.ParseOwner(value), false},
+		{"test.example.com.", "A", "cluster-a", &forged, false},
+		{"test.example.com.", "A", "cluster-a", copied, false},
+		{"test.example.com.", "A", "cluster-a", pkg.ParseOwner(`"mate:test"`), false},
+		{"test.example.com.", "A", "cluster-a", pkg.ParseOwner(`"heritage=mate" "mate/record-group-id=test"`), false},
+	} {
+		r := &plan.Record{Name: test.name, Type: test.recordType, SetIdentifier: test.setIdentifier, Owner: test.owner}
+		if owned := client.isOwner(r); owned != test.owned {
+			t.Errorf("isOwner(%s %s %s, %v) => %t, want %t", test.name, test.recordType, test.setIdentifier, test.owner, owned, test.owned)
+		}
+	}
+
+	//names with an ownership record only are verified against the type the name of the ownership record implies
+	cname := client.owner(&plan.Record{Name: "test.example.com.", Type: "CNAME"}, "service/default/foo")
+	for _, test := range []struct {
+		ownerName string
+		owned     bool
+	}{
+		{"_mate-cname.test.example.com.", true},
+		{"test.example.com.", false},
+	} {
+		r := &plan.Record{Name: "test.example.com.", Owner: cname, OwnerRecord: &plan.Record{Name: test.ownerName, Type: "TXT"}}
+		if owned := client.isOwner(r); owned != test.owned {
+			t.Errorf("isOwner() of ownership record %s => %t, want %t", test.ownerName, owned, test.owned)
+		}
 	}
 }
 
-func TestGetRecordTarget(t *testing.T) {
+func TestReportInvalidClaims(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	key := []byte("secret")
+	signed := newOwner(&plan.Record{Name: "signed.example.com.", Type: "A"}, "service/default/foo", "test", "", key)
+	records := []*plan.Record{
+		{Name: "signed.example.com.", Type: "A", Owner: signed},
+		{Name: "unsigned.example.com.", Owner: pkg.ParseOwner(`"mate:test"`)},
+		{Name: "other.example.com.", Owner: pkg.ParseOwner(`"mate:other"`)},
+		{Name: "unowned.example.com."},
+	}
+
+	reportInvalidClaims(records, "test", nil)
+	if buf.Len() != 0 {
+		t.Errorf("Reported claims without a key: %s", buf.String())
+	}
+
+	reportInvalidClaims(records, "test", key)
+	if n := strings.Count(buf.String(), "Ignoring ownership claim"); n != 1 || !strings.Contains(buf.String(), "unsigned.example.com.") {
+		t.Errorf("Expected only the claim of unsigned.example.com. to be reported, got: %s", buf.String())
+	}
+}
+
+func TestIsOwner(t *testing.T) {
+	client := &awsConsumer{
+		groupID: "test",
+	}
+	for _, test := range []struct {
+		value string
+		owned bool
+	}{
+		{`"mate:test"`, true},
+		{`"mate:other"`, false},
+		{`"heritage=mate" "mate/record-group-id=test" "mate/cluster=foo"`, true},
+		{`"heritage=mate" "mate/record-group-id=other"`, false},
+		{`"mate/record-group-id=test"`, false},
+		{`"lonely"`, false},
+	} {
+		if owned := client.isOwner(&plan.Record{Name: "test.example.com.", Type: "A", Owner: pkg.ParseOwner(test.value)}); owned != test.owned {
+			t.Errorf("isOwner(%s) => %t, want %t", test.value, owned, test.owned)
+		}
+	}
+}
+
+func TestGetRecordTargets(t *testing.T) {
 	groupID := "test"
 	client := &awsConsumer{
 		groupID: groupID,
@@ -417,14 +447,14 @@ func TestGetRecordTarget(t *testing.T) {
 		},
 	}
 
-	if target := client.getRecordTarget(r1); target != "200.elb.com" {
-		t.Errorf("Incorrect target extracted for %v, expected: %s, got: %s", r1, "200.elb.com", target)
+	if targets := client.getRecordTargets(r1); len(targets) != 1 || targets[0] != "200.elb.com" {
+		t.Errorf("Incorrect target extracted for %v, expected: %s, got: %v", r1, "200.elb.com", targets)
 	}
-	if target := client.getRecordTarget(r2); target != "" {
-		t.Errorf("Incorrect target extracted for %v, expected: %s, got: %s", r2, "", target)
+	if targets := client.getRecordTargets(r2); len(targets) != 1 || targets[0] != "ignored" {
+		t.Errorf("Incorrect target extracted for %v, expected: %s, got: %v", r2, "ignored", targets)
 	}
-	if target := client.getRecordTarget(r3); target != "some-elb.amazon.com" {
-		t.Errorf("Incorrect target extracted for %v, expected: %s, got: %s", r3, "some-elb-amazon.com", target)
+	if targets := client.getRecordTargets(r3); len(targets) != 1 || targets[0] != "some-elb.amazon.com" {
+		t.Errorf("Incorrect target extracted for %v, expected: %s, got: %v", r3, "some-elb-amazon.com", targets)
 	}
 }
 
@@ -510,22 +540,22 @@ func TestAWSConsumer(t *testing.T) { //exclude IP endpoints for now only Alias w
 			msg: "two new fighting services",
 			sync: []*pkg.Endpoint{
 				{
-					"test.example.com", "", "301.elb.com",
+					DNSName: "test.example.com", IP: "", Hostname: "301.elb.com",
 				},
 				{
-					"test.example.com", "", "401.elb.com",
+					DNSName: "test.example.com", IP: "", Hostname: "401.elb.com",
 				},
 				{
-					"update.example.com", "", "elb.com",
+					DNSName: "update.example.com", IP: "", Hostname: "elb.com",
 				},
 				{
-					"withouttxt.example.com", "", "random.com",
+					DNSName: "withouttxt.example.com", IP: "", Hostname: "random.com",
 				},
 				{
-					"nest.sub.example.com", "", "nested.elb",
+					DNSName: "nest.sub.example.com", IP: "", Hostname: "nested.elb",
 				},
 				{
-					"ip.sub.example.com", "192.168.0.1", "",
+					DNSName: "ip.sub.example.com", IP: "192.168.0.1", Hostname: "",
 				},
 			},
 			expectUpsert: map[string][]*route53.ResourceRecordSet{
@@ -617,19 +647,19 @@ func TestAWSConsumer(t *testing.T) { //exclude IP endpoints for now only Alias w
 			msg: "two fighting services, one old, one new",
 			sync: []*pkg.Endpoint{
 				{
-					"test.example.com", "", "302.elb.com",
+					DNSName: "test.example.com", IP: "", Hostname: "302.elb.com",
 				},
 				{
-					"test.example.com", "", "404.elb.com",
+					DNSName: "test.example.com", IP: "", Hostname: "404.elb.com",
 				},
 				{
-					"update.example.com", "", "elb.com",
+					DNSName: "update.example.com", IP: "", Hostname: "elb.com",
 				},
 				{
-					"withouttxt.example.com", "", "random.com",
+					DNSName: "withouttxt.example.com", IP: "", Hostname: "random.com",
 				},
 				{
-					"nest.sub.example.com", "", "nested.elb",
+					DNSName: "nest.sub.example.com", IP: "", Hostname: "nested.elb",
 				},
 			},
 			expectUpsert: map[string][]*route53.ResourceRecordSet{
@@ -696,16 +726,16 @@ func TestAWSConsumer(t *testing.T) { //exclude IP endpoints for now only Alias w
 			msg: "partial overlap",
 			sync: []*pkg.Endpoint{
 				{
-					"test.example.com", "", "404.elb.com",
+					DNSName: "test.example.com", IP: "", Hostname: "404.elb.com",
 				},
 				{
-					"update.example.com", "", "elb.com",
+					DNSName: "update.example.com", IP: "", Hostname: "elb.com",
 				},
 				{
-					"withouttxt.example.com", "", "random.com",
+					DNSName: "withouttxt.example.com", IP: "", Hostname: "random.com",
 				},
 				{
-					"nest.sub.example.com", "", "nested.elb",
+					DNSName: "nest.sub.example.com", IP: "", Hostname: "nested.elb",
 				},
 			},
 			expectUpsert: map[string][]*route53.ResourceRecordSet{
@@ -771,9 +801,9 @@ func TestAWSConsumer(t *testing.T) { //exclude IP endpoints for now only Alias w
 		{
 			msg: "no initial, sync new ones",
 			sync: []*pkg.Endpoint{{
-				"test.example.com", "", "abc.def.ghi",
+				DNSName: "test.example.com", IP: "", Hostname: "abc.def.ghi",
 			}, {
-				"withouttxt.example.com", "", "random.com",
+				DNSName: "withouttxt.example.com", IP: "", Hostname: "random.com",
 			}},
 			expectUpsert: map[string][]*route53.ResourceRecordSet{
 				"example.com.": []*route53.ResourceRecordSet{
@@ -838,9 +868,9 @@ func TestAWSConsumer(t *testing.T) { //exclude IP endpoints for now only Alias w
 		{
 			msg: "sync delete all",
 			sync: []*pkg.Endpoint{{
-				"another.example.com", "", "abc.def.ghi",
+				DNSName: "another.example.com", IP: "", Hostname: "abc.def.ghi",
 			}, {
-				"cname.example.com", "", "hello.elb.com",
+				DNSName: "cname.example.com", IP: "", Hostname: "hello.elb.com",
 			}},
 			expectDelete: map[string][]*route53.ResourceRecordSet{
 				"example.com.": []*route53.ResourceRecordSet{
@@ -900,13 +930,13 @@ func TestAWSConsumer(t *testing.T) { //exclude IP endpoints for now only Alias w
 		}, {
 			msg: "insert, update, delete, leave",
 			sync: []*pkg.Endpoint{{
-				"new.example.com", "", "qux.elb",
+				DNSName: "new.example.com", IP: "", Hostname: "qux.elb",
 			}, {
-				"test.example.com", "", "foo.elb2",
+				DNSName: "test.example.com", IP: "", Hostname: "foo.elb2",
 			}, {
-				"test.foo.com", "", "foo.loadbalancer", //skip it
+				DNSName: "test.foo.com", IP: "", Hostname: "foo.loadbalancer", //skip it
 			}, {
-				"update.foo.com", "", "new.loadbalancer",
+				DNSName: "update.foo.com", IP: "", Hostname: "new.loadbalancer",
 			}},
 			expectUpsert: map[string][]*route53.ResourceRecordSet{
 				"foo.com.": []*route53.ResourceRecordSet{
@@ -1027,3 +1057,532 @@ func TestAWSConsumer(t *testing.T) { //exclude IP endpoints for now only Alias w
 		})
 	}
 }
+
+func TestAWSConsumerDryRun(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+
+	consumer := withClient(client, groupID)
+	consumer.dryRun = true
+
+	err := consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "new.example.com", Hostname: "qux.elb"},
+		{DNSName: "update.foo.com", Hostname: "new.loadbalancer"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = consumer.Process(&pkg.Endpoint{DNSName: "process.example.com.", Hostname: "cool.elb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if NonEmptyMapLength(client.LastUpsert) != 0 || NonEmptyMapLength(client.LastDelete) != 0 || NonEmptyMapLength(client.LastCreate) != 0 {
+		t.Error("dry run should not change any records", client.LastUpsert, client.LastDelete, client.LastCreate)
+	}
+}
+
+func TestAWSConsumerPlanApply(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	consumer := withClient(client, groupID)
+
+	p, err := consumer.Plan([]*pkg.Endpoint{{DNSName: "new.example.com", Hostname: "qux.elb"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Zones) != len(client.HostedZones) {
+		t.Fatalf("expected a plan for each of the %d hosted zones, got %d", len(client.HostedZones), len(p.Zones))
+	}
+	if NonEmptyMapLength(client.LastUpsert) != 0 || NonEmptyMapLength(client.LastDelete) != 0 {
+		t.Fatal("planning should not change any records", client.LastUpsert, client.LastDelete)
+	}
+
+	if err := consumer.Apply(p); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.LastUpsert["example.com."]) != 2 {
+		t.Errorf("expected the record and its ownership record to be created, got %v", client.LastUpsert["example.com."])
+	}
+
+	client.Current["example.com."] = append(client.Current["example.com."], client.LastUpsert["example.com."]...)
+	if err := consumer.Apply(p); err == nil {
+		t.Error("expected applying a plan for a changed hosted zone to fail")
+	}
+}
+
+func TestAWSConsumerMaxDeletions(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+
+	consumer := withClient(client, groupID)
+	consumer.maxDeletions = &plan.DeletionLimit{Percentage: 50}
+
+	if err := consumer.Sync(nil); err == nil {
+		t.Error("expected deleting all owned records to be refused")
+	}
+	if NonEmptyMapLength(client.LastUpsert) != 0 || NonEmptyMapLength(client.LastDelete) != 0 {
+		t.Error("refused sync should not change any records", client.LastUpsert, client.LastDelete)
+	}
+
+	consumer.maxDeletions = nil
+	if err := consumer.Sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	if NonEmptyMapLength(client.LastDelete) == 0 {
+		t.Error("expected owned records to be deleted without a limit")
+	}
+}
+
+func TestAWSConsumerUpsertOnlyPolicy(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+
+	consumer := withClient(client, groupID)
+	consumer.policy = plan.UpsertOnlyPolicy
+
+	err := consumer.Sync([]*pkg.Endpoint{{DNSName: "new.example.com", Hostname: "qux.elb"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if NonEmptyMapLength(client.LastDelete) != 0 {
+		t.Error("upsert-only policy should not delete any records", client.LastDelete)
+	}
+	if len(client.LastUpsert["example.com."]) == 0 {
+		t.Error("upsert-only policy should create new records")
+	}
+}
+
+func TestAWSConsumerDomainFilter(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+
+	consumer := withClient(client, groupID)
+	consumer.domainFilter = pkg.DomainFilter{Include: []string{"example.com"}, Exclude: []string{"sub.example.com"}}
+
+	err := consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "new.example.com", Hostname: "qux.elb"},
+		{DNSName: "new.sub.example.com", Hostname: "qux.elb"},
+		{DNSName: "new.foo.com", Hostname: "qux.elb"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(client.LastUpsert["example.com."]) != 2 {
+		t.Errorf("expected new.example.com to be created, got %v", client.LastUpsert["example.com."])
+	}
+	if len(client.LastUpsert["sub.example.com."]) != 0 || len(client.LastDelete["sub.example.com."]) != 0 {
+		t.Error("expected excluded zone sub.example.com to be left alone", client.LastUpsert["sub.example.com."], client.LastDelete["sub.example.com."])
+	}
+	if len(client.LastUpsert["foo.com."]) != 0 || len(client.LastDelete["foo.com."]) != 0 {
+		t.Error("expected zone foo.com outside of the domain filter to be left alone", client.LastUpsert["foo.com."], client.LastDelete["foo.com."])
+	}
+
+	err = consumer.Process(&pkg.Endpoint{DNSName: "process.foo.com", Hostname: "qux.elb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.LastCreate["foo.com."]) != 0 {
+		t.Error("expected process.foo.com not to be created", client.LastCreate["foo.com."])
+	}
+}
+
+func TestAWSConsumerHostedZoneSelection(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	client.HostedZones["private-example.com."] = &awsclient.HostedZone{ID: "private-example.com.", Name: "example.com.", Private: true}
+	client.ZoneTags = map[string]map[string]string{
+		"example.com.":         {"team": "foo"},
+		"private-example.com.": {"team": "foo"},
+		"foo.com.":             {"team": "bar"},
+	}
+
+	consumer := withClient(client, groupID)
+
+	zones, err := consumer.hostedZones()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 4 {
+		t.Errorf("expected all hosted zones to be selected, got %v", zones)
+	}
+
+	consumer.zoneTags = map[string]string{"team": "foo"}
+	consumer.zoneType = "public"
+	zones, err = consumer.hostedZones()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := zones["example.com."]; len(zones) != 1 || !exists {
+		t.Errorf("expected only the public example.com. zone to be selected, got %v", zones)
+	}
+
+	consumer = withClient(client, groupID)
+	consumer.zoneIDs = []string{"/hostedzone/foo.com."}
+	zones, err = consumer.hostedZones()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := zones["foo.com."]; len(zones) != 1 || !exists {
+		t.Errorf("expected only the foo.com. zone to be selected, got %v", zones)
+	}
+}
+
+func TestAWSConsumerSplitHorizon(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	client.HostedZones["private-example.com."] = &awsclient.HostedZone{ID: "private-example.com.", Name: "example.com.", Private: true}
+
+	consumer := withClient(client, groupID)
+	err := consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "public.example.com", Hostname: "public.elb"},
+		{DNSName: "private.example.com", Hostname: "internal.elb", Annotations: map[string]string{zoneTypeAnnotation: "private"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if upsert := client.LastUpsert["example.com."]; len(upsert) != 2 || aws.StringValue(upsert[0].Name) != "public.example.com." {
+		t.Errorf("expected public.example.com to be created in the public zone, got %v", upsert)
+	}
+	if upsert := client.LastUpsert["private-example.com."]; len(upsert) != 2 || aws.StringValue(upsert[0].Name) != "private.example.com." {
+		t.Errorf("expected private.example.com to be created in the private zone, got %v", upsert)
+	}
+}
+
+func TestAWSConsumerCNAMEFallback(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	client.UnknownLBs = map[string]bool{"unknown.example.org": true}
+
+	consumer := withClient(client, groupID)
+	err := consumer.Sync([]*pkg.Endpoint{{DNSName: "new.example.com", Hostname: "unknown.example.org"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upsert := client.LastUpsert["example.com."]
+	if len(upsert) != 2 || aws.StringValue(upsert[0].Type) != "CNAME" || upsert[0].AliasTarget != nil ||
+		aws.StringValue(upsert[0].ResourceRecords[0].Value) != "unknown.example.org." {
+		t.Errorf("expected a CNAME record pointing to unknown.example.org., got %v", upsert)
+	}
+	if len(upsert) == 2 && aws.StringValue(upsert[1].Name) != "_mate-cname.new.example.com." {
+		t.Errorf("expected the ownership of the CNAME record to be stored next to it, got %v", upsert[1])
+	}
+
+	client.Current["example.com."] = append(client.Current["example.com."], upsert...)
+	client.LastUpsert = map[string][]*route53.ResourceRecordSet{}
+	if err := consumer.Sync([]*pkg.Endpoint{{DNSName: "new.example.com", Hostname: "unknown.example.org"}}); err != nil {
+		t.Fatal(err)
+	}
+	if upsert := client.LastUpsert["example.com."]; len(upsert) != 0 {
+		t.Errorf("expected the CNAME record to be recognized as owned and unchanged, got %v", upsert)
+	}
+}
+
+func TestAWSConsumerLBLookupError(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	client.LBLookupErr = fmt.Errorf("throttled")
+
+	consumer := withClient(client, groupID)
+	err := consumer.Sync([]*pkg.Endpoint{{DNSName: "new.example.com", Hostname: "foo-1234567890.eu-central-1.elb.amazonaws.com"}})
+	if err == nil {
+		t.Error("expected the sync to fail if load balancers can't be looked up")
+	}
+	if len(client.LastUpsert) != 0 || len(client.LastCreate) != 0 || len(client.LastDelete) != 0 {
+		t.Errorf("expected no changes, got upserts %v, creates %v and deletions %v", client.LastUpsert, client.LastCreate, client.LastDelete)
+	}
+}
+
+func TestAWSConsumerWeightedRecords(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	client.Current["example.com."] = append(client.Current["example.com."],
+		&route53.ResourceRecordSet{
+			Name:            aws.String("weighted.example.com."),
+			Type:            aws.String("A"),
+			SetIdentifier:   aws.String("other-cluster"),
+			Weight:          aws.Int64(10),
+			TTL:             aws.Int64(defaultATTL),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("8.8.8.8")}},
+		},
+		&route53.ResourceRecordSet{
+			Name:            aws.String("weighted.example.com."),
+			Type:            aws.String("TXT"),
+			SetIdentifier:   aws.String("other-cluster"),
+			Weight:          aws.Int64(10),
+			TTL:             aws.Int64(defaultTxtTTL),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("\"mate:other-group\"")}},
+		},
+	)
+
+	consumer := withClient(client, groupID)
+	consumer.cluster = "this-cluster"
+	err := consumer.Sync([]*pkg.Endpoint{{
+		DNSName: "weighted.example.com",
+		IP:      "1.2.3.4",
+		Annotations: map[string]string{
+			pkg.RoutingPolicyAnnotation: pkg.WeightedRouting,
+			pkg.RoutingWeightAnnotation: "20",
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upsert := client.LastUpsert["example.com."]
+	if len(upsert) != 2 {
+		t.Fatalf("expected a weighted record and its ownership record, got %v", upsert)
+	}
+	for _, rs := range upsert {
+		if aws.StringValue(rs.SetIdentifier) != "this-cluster" || aws.Int64Value(rs.Weight) != 20 {
+			t.Errorf("expected %s record with set identifier this-cluster and weight 20, got %v", aws.StringValue(rs.Type), rs)
+		}
+	}
+	for _, rs := range client.LastDelete["example.com."] {
+		if aws.StringValue(rs.Name) == "weighted.example.com." {
+			t.Errorf("expected the member of the other cluster to be kept, got deletion %v", rs)
+		}
+	}
+
+	client.Current["example.com."] = append(client.Current["example.com."], upsert...)
+	client.LastDelete = map[string][]*route53.ResourceRecordSet{}
+	if err := consumer.Sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	deleted := 0
+	for _, rs := range client.LastDelete["example.com."] {
+		if aws.StringValue(rs.Name) != "weighted.example.com." {
+			continue
+		}
+		deleted++
+		if aws.StringValue(rs.SetIdentifier) != "this-cluster" {
+			t.Errorf("expected only the member of this cluster to be deleted, got %v", rs)
+		}
+	}
+	if deleted != 2 {
+		t.Errorf("expected the weighted record of this cluster and its ownership record to be deleted, got %d deletions", deleted)
+	}
+}
+
+func TestAWSConsumerWeightedRecordsSharedGroup(t *testing.T) {
+	groupID := "shared"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	endpoint := &pkg.Endpoint{
+		DNSName: "weighted.example.com",
+		IP:      "1.2.3.4",
+		Annotations: map[string]string{
+			pkg.RoutingPolicyAnnotation: pkg.WeightedRouting,
+			pkg.RoutingWeightAnnotation: "10",
+		},
+	}
+
+	for _, cluster := range []string{"cluster-a", "cluster-b"} {
+		consumer := withClient(client, groupID)
+		consumer.cluster = cluster
+		client.LastUpsert = map[string][]*route53.ResourceRecordSet{}
+		if err := consumer.Sync([]*pkg.Endpoint{endpoint}); err != nil {
+			t.Fatal(err)
+		}
+		client.Current["example.com."] = append(client.Current["example.com."], client.LastUpsert["example.com."]...)
+	}
+
+	consumer := withClient(client, groupID)
+	consumer.cluster = "cluster-a"
+	client.LastUpsert = map[string][]*route53.ResourceRecordSet{}
+	client.LastDelete = map[string][]*route53.ResourceRecordSet{}
+	if err := consumer.Sync([]*pkg.Endpoint{endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	for _, rs := range append(client.LastUpsert["example.com."], client.LastDelete["example.com."]...) {
+		if aws.StringValue(rs.Name) == "weighted.example.com." {
+			t.Errorf("expected the members of both clusters to be kept, got change of %s %s", aws.StringValue(rs.Type), aws.StringValue(rs.SetIdentifier))
+		}
+	}
+
+	client.LastDelete = map[string][]*route53.ResourceRecordSet{}
+	if err := consumer.Sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, rs := range client.LastDelete["example.com."] {
+		if aws.StringValue(rs.Name) == "weighted.example.com." && aws.StringValue(rs.SetIdentifier) != "cluster-a" {
+			t.Errorf("expected only the member of cluster-a to be deleted, got %s %s", aws.StringValue(rs.Type), aws.StringValue(rs.SetIdentifier))
+		}
+	}
+}
+
+func TestAWSConsumerHealthChecks(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	consumer := withClient(client, groupID)
+	original := client.Current["example.com."]
+
+	endpoint := &pkg.Endpoint{
+		DNSName: "checked.example.com",
+		IP:      "1.2.3.4",
+		Annotations: map[string]string{
+			pkg.HealthCheckTypeAnnotation: "http",
+			pkg.HealthCheckPathAnnotation: "/healthz",
+		},
+	}
+	sync := func() []*route53.ResourceRecordSet {
+		client.LastUpsert = map[string][]*route53.ResourceRecordSet{}
+		if err := consumer.Sync([]*pkg.Endpoint{endpoint}); err != nil {
+			t.Fatal(err)
+		}
+		var upserted []*route53.ResourceRecordSet
+		for _, rs := range client.LastUpsert["example.com."] {
+			if aws.StringValue(rs.Name) == "checked.example.com." {
+				upserted = append(upserted, rs)
+			}
+		}
+		if len(upserted) > 0 {
+			client.Current["example.com."] = append(original[:len(original):len(original)], upserted...)
+		}
+		return upserted
+	}
+
+	if err := consumer.Sync([]*pkg.Endpoint{{DNSName: "unchecked.example.com", IP: "1.2.3.4"}}); err != nil {
+		t.Fatal(err)
+	}
+	if client.HealthChecksListed != 0 {
+		t.Errorf("expected health checks not to be listed without records using them, got %d calls", client.HealthChecksListed)
+	}
+	client.Current["example.com."] = original
+
+	upserted := sync()
+	if len(upserted) != 2 || len(client.HealthChecks) != 1 {
+		t.Fatalf("expected the record to be created along with a health check, got %v and %v", upserted, client.HealthChecks)
+	}
+	id := aws.StringValue(upserted[0].HealthCheckId)
+	check := client.HealthChecks[id]
+	if check == nil || aws.StringValue(check.HealthCheckConfig.IPAddress) != "1.2.3.4" || aws.StringValue(check.HealthCheckConfig.ResourcePath) != "/healthz" {
+		t.Fatalf("expected the record to refer to a health check of 1.2.3.4/healthz, got %v", check)
+	}
+
+	if upserted := sync(); len(upserted) != 0 {
+		t.Errorf("expected no changes for an unchanged health check, got %v", upserted)
+	}
+
+	endpoint.Annotations[pkg.HealthCheckPathAnnotation] = "/ready"
+	upserted = sync()
+	if len(upserted) != 2 || aws.StringValue(upserted[0].HealthCheckId) == id {
+		t.Fatalf("expected the record to refer to a new health check, got %v", upserted)
+	}
+	if _, exists := client.HealthChecks[id]; exists || len(client.HealthChecks) != 1 {
+		t.Errorf("expected the previous health check %s to be deleted, got %v", id, client.HealthChecks)
+	}
+
+	endpoint.Annotations[pkg.HealthCheckPathAnnotation] = "/healthz"
+	upserted = sync()
+	if len(upserted) != 2 || len(client.HealthChecks) != 1 {
+		t.Fatalf("expected the record to refer to a new health check, got %v and %v", upserted, client.HealthChecks)
+	}
+	if check := client.HealthChecks[aws.StringValue(upserted[0].HealthCheckId)]; check == nil || aws.StringValue(check.HealthCheckConfig.ResourcePath) != "/healthz" {
+		t.Errorf("expected the health check of 1.2.3.4/healthz to be created again, got %v", check)
+	}
+
+	if err := consumer.Sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.HealthChecks) != 0 {
+		t.Errorf("expected the health check to be deleted along with the record, got %v", client.HealthChecks)
+	}
+}
+
+func TestAWSConsumerSweepsHealthChecks(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	consumer := withClient(client, groupID)
+	client.HealthChecks["foreign"] = &route53.HealthCheck{Id: aws.String("foreign"), CallerReference: aws.String("mate/other/1")}
+
+	endpoint := &pkg.Endpoint{
+		DNSName:     "checked.example.com",
+		IP:          "1.2.3.4",
+		Annotations: map[string]string{pkg.HealthCheckTypeAnnotation: "http", pkg.HealthCheckPathAnnotation: "/healthz"},
+	}
+	client.ChangeErr = fmt.Errorf("throttled")
+	if err := consumer.Sync([]*pkg.Endpoint{endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	var leaked string
+	for id := range client.HealthChecks {
+		if id != "foreign" {
+			leaked = id
+		}
+	}
+	if leaked == "" || len(client.LastUpsert["example.com."]) != 0 {
+		t.Fatalf("expected a health check to be created without changing the records, got %v and %v", client.HealthChecks, client.LastUpsert)
+	}
+
+	client.ChangeErr = nil
+	endpoint.Annotations[pkg.HealthCheckPathAnnotation] = "/ready"
+	if err := consumer.Sync([]*pkg.Endpoint{endpoint}); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := client.HealthChecks[leaked]; exists {
+		t.Errorf("expected the unused health check %s to be deleted, got %v", leaked, client.HealthChecks)
+	}
+	if _, exists := client.HealthChecks["foreign"]; !exists {
+		t.Errorf("expected the health check of another group to be kept, got %v", client.HealthChecks)
+	}
+	upserted := client.LastUpsert["example.com."]
+	if len(upserted) != 2 || client.HealthChecks[aws.StringValue(upserted[0].HealthCheckId)] == nil || len(client.HealthChecks) != 2 {
+		t.Errorf("expected the record to refer to the only health check of the group, got %v and %v", upserted, client.HealthChecks)
+	}
+}
+
+func TestAWSConsumerMergesIPs(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	consumer := withClient(client, groupID)
+
+	err := consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "nodes.example.com", IP: "10.0.0.1"},
+		{DNSName: "nodes.example.com", IP: "10.0.0.2"},
+		{DNSName: "nodes.example.com", IP: "10.0.0.1"},
+		{DNSName: "nodes.example.com", IP: "10.0.0.3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upsert := client.LastUpsert["example.com."]
+	if len(upsert) != 2 || aws.StringValue(upsert[0].Type) != "A" {
+		t.Fatalf("expected one A record and its ownership record, got %v", upsert)
+	}
+	var ips []string
+	for _, rr := range upsert[0].ResourceRecords {
+		ips = append(ips, aws.StringValue(rr.Value))
+	}
+	if strings.Join(ips, ",") != "10.0.0.1,10.0.0.2,10.0.0.3" {
+		t.Errorf("expected the record to point to all node IPs, got %v", ips)
+	}
+}
+
+func TestAWSConsumerHealthCheckSeveralIPs(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	consumer := withClient(client, groupID)
+
+	annotations := map[string]string{pkg.HealthCheckTypeAnnotation: "http"}
+	err := consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "nodes.example.com", IP: "10.0.0.1", Annotations: annotations},
+		{DNSName: "nodes.example.com", IP: "10.0.0.2", Annotations: annotations},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upsert := client.LastUpsert["example.com."]
+	if len(upsert) != 2 || len(upsert[0].ResourceRecords) != 2 {
+		t.Fatalf("expected one A record with both IPs and its ownership record, got %v", upsert)
+	}
+	if upsert[0].HealthCheckId != nil || len(client.HealthChecks) != 0 {
+		t.Errorf("expected no health check for a record with several IPs, got %v", client.HealthChecks)
+	}
+}
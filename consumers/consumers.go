@@ -4,6 +4,7 @@ import (
 	"sync"
 
 	"github.com/zalando-incubator/mate/pkg"
+	"github.com/zalando-incubator/mate/plan"
 )
 
 // Consumer interface
@@ -12,3 +13,17 @@ type Consumer interface {
 	Consume(<-chan *pkg.Endpoint, chan<- error, <-chan struct{}, *sync.WaitGroup)
 	Process(*pkg.Endpoint) error
 }
+
+// Planner is implemented by consumers that can compute the changes for a set
+// of endpoints without applying them, and apply them later on.
+type Planner interface {
+	Plan([]*pkg.Endpoint) (*plan.Plan, error)
+	Apply(*plan.Plan) error
+}
+
+// endpointsProcessor is implemented by consumers that can process several
+// endpoints of the same DNS name at once, e.g. all IPs of a hostname, so that
+// they end up in a single record.
+type endpointsProcessor interface {
+	processEndpoints([]*pkg.Endpoint) error
+}
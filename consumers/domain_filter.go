@@ -0,0 +1,21 @@
+package consumers
+
+import (
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/zalando-incubator/mate/pkg"
+)
+
+// filterEndpoints returns the endpoints whose DNS name is matched by the
+// filter, skipping all others.
+func filterEndpoints(endpoints []*pkg.Endpoint, filter pkg.DomainFilter) []*pkg.Endpoint {
+	filtered := make([]*pkg.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if !filter.Match(ep.DNSName) {
+			log.Debugf("Skipping endpoint %s: not matched by the domain filter", ep.DNSName)
+			continue
+		}
+		filtered = append(filtered, ep)
+	}
+	return filtered
+}
@@ -3,10 +3,14 @@ package consumers
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/zalando-incubator/mate/pkg"
+	"github.com/zalando-incubator/mate/plan"
 
 	log "github.com/Sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -14,123 +18,232 @@ import (
 	"google.golang.org/api/dns/v1"
 )
 
-const (
-	heritageLabel = "heritage=mate"
-	labelPrefix   = "mate/record-group-id="
-)
-
 type googleDNSConsumer struct {
-	client  *dns.Service
-	zones   map[string]*dns.ManagedZone
-	labels  []string
-	groupID string
-	project string
+	basePath     string
+	httpClient   *http.Client
+	groupID      string
+	cluster      string
+	key          []byte
+	dryRun       bool
+	maxDeletions *plan.DeletionLimit
+	policy       plan.Policy
+	domainFilter pkg.DomainFilter
+	project      string
+
+	// retries and backoff of failed requests, and the interval and timeout
+	// of waiting for changes to be done
+	retries      int
+	backoff      time.Duration
+	pollInterval time.Duration
+	timeout      time.Duration
+
+	// selection of the managed zones, which are listed again once the
+	// refresh interval has passed
+	zoneNames           []string
+	zoneLabels          map[string]string
+	zoneVisibility      string
+	zoneRefreshInterval time.Duration
+	zonesMu             sync.Mutex
+	zones               map[string]*managedZone
+	zonesRefreshed      time.Time
+}
+
+// GoogleOptions configures the Google CloudDNS consumer.
+type GoogleOptions struct {
+	Project       string
+	RecordGroupID string
+	ClusterName   string
+	OwnershipKey  []byte
+	DryRun        bool
+	MaxDeletions  *plan.DeletionLimit
+	Policy        plan.Policy
+	DomainFilter  pkg.DomainFilter
+
+	// Restrict the managed zones to the ones with the given names, with all
+	// of the given labels and of the given visibility, "public" or
+	// "private". Zones are listed again every ZoneRefreshInterval, which
+	// defaults to a minute, so new zones are picked up.
+	Zones               []string
+	ZoneLabels          map[string]string
+	ZoneVisibility      string
+	ZoneRefreshInterval time.Duration
+
+	// Override the Cloud DNS API base path, e.g. to use a local stand-in,
+	// and the service account key file used instead of the default
+	// credentials.
+	Endpoint        string
+	CredentialsFile string
 }
 
 type ownedRecord struct {
-	owner  *dns.ResourceRecordSet
-	record *dns.ResourceRecordSet
+	owner  *recordSet
+	record *recordSet
 }
 
-// NewGoogleCloudDNSConsumer creates
-func NewGoogleCloudDNSConsumer(googleProject, googleRecordGroupID string) (Consumer, error) {
-	if googleProject == "" {
+// NewGoogleCloudDNSConsumer creates a Consumer instance to sync and process
+// DNS entries in Google CloudDNS.
+func NewGoogleCloudDNSConsumer(opts *GoogleOptions) (Consumer, error) {
+	if opts.Project == "" {
 		return nil, errors.New("Please provide --google-project")
 	}
 
-	if googleRecordGroupID == "" {
+	if opts.RecordGroupID == "" {
 		return nil, errors.New("Please provide --google-record-group-id")
 	}
 
+	gcloud, err := newGoogleClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := googleDNSBasePath
+	if opts.Endpoint != "" {
+		basePath = strings.TrimSuffix(opts.Endpoint, "/") + "/"
+	}
+
+	refreshInterval := opts.ZoneRefreshInterval
+	if refreshInterval == 0 {
+		refreshInterval = defaultGoogleZoneRefreshInterval
+	}
+
+	d := &googleDNSConsumer{
+		basePath:     basePath,
+		httpClient:   gcloud,
+		groupID:      opts.RecordGroupID,
+		cluster:      opts.ClusterName,
+		key:          opts.OwnershipKey,
+		dryRun:       opts.DryRun,
+		maxDeletions: opts.MaxDeletions,
+		policy:       opts.Policy,
+		domainFilter: opts.DomainFilter,
+		project:      opts.Project,
+		retries:      defaultGoogleRetries,
+		backoff:      defaultGoogleBackoff,
+		pollInterval: defaultGooglePollInterval,
+		timeout:      defaultGoogleTimeout,
+
+		zoneNames:           opts.Zones,
+		zoneLabels:          opts.ZoneLabels,
+		zoneVisibility:      opts.ZoneVisibility,
+		zoneRefreshInterval: refreshInterval,
+	}
+
+	if _, err := d.managedZones(); err != nil {
+		return nil, fmt.Errorf("Error getting managed zones in project %s: %v", opts.Project, err)
+	}
+
+	return d, nil
+}
+
+// newGoogleClient returns an HTTP client authorized with the service account
+// key file if given, or else with the default credentials. Without any
+// credentials, requests to a custom endpoint are sent unauthenticated.
+func newGoogleClient(opts *GoogleOptions) (*http.Client, error) {
+	if opts.CredentialsFile != "" {
+		key, err := ioutil.ReadFile(opts.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading credentials file: %v", err)
+		}
+
+		config, err := google.JWTConfigFromJSON(key, dns.NdevClouddnsReadwriteScope)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing credentials file %s: %v", opts.CredentialsFile, err)
+		}
+
+		return config.Client(context.Background()), nil
+	}
+
 	gcloud, err := google.DefaultClient(context.Background(), dns.NdevClouddnsReadwriteScope)
 	if err != nil {
+		if opts.Endpoint != "" {
+			log.Warnf("No default credentials found, sending unauthenticated requests to %s: %v", opts.Endpoint, err)
+			return http.DefaultClient, nil
+		}
 		return nil, fmt.Errorf("Error creating default client: %v", err)
 	}
 
-	client, err := dns.New(gcloud)
+	return gcloud, nil
+}
+
+func (d *googleDNSConsumer) Sync(endpoints []*pkg.Endpoint) error {
+	p, err := d.Plan(endpoints)
 	if err != nil {
-		return nil, fmt.Errorf("Error creating DNS service: %v", err)
+		return err
 	}
 
-	resp, err := client.ManagedZones.List(googleProject).Do()
+	err = d.apply(p)
 	if err != nil {
-		return nil, fmt.Errorf("Error getting managed zones in project %s: %v", googleProject, err)
+		return fmt.Errorf("Error applying change for project %s: %v", d.project, err)
 	}
 
-	zones := make(map[string]*dns.ManagedZone)
-	for _, z := range resp.ManagedZones {
-		zones[z.DnsName] = z
+	return nil
+}
+
+// Plan computes the changes for all managed zones without applying them.
+func (d *googleDNSConsumer) Plan(endpoints []*pkg.Endpoint) (*plan.Plan, error) {
+	zones, err := d.managedZones()
+	if err != nil {
+		return nil, fmt.Errorf("Error getting managed zones in project %s: %v", d.project, err)
 	}
 
-	labels := []string{heritageLabel, labelPrefix + googleRecordGroupID}
+	desired := make(map[string][]*plan.Record)
+	for _, r := range d.desiredRecords(filterEndpoints(endpoints, d.domainFilter)) {
+		zone := zoneFor(zones, r.Name)
+		if zone == nil {
+			log.Warnf("Managed zone for endpoint: %s was not found. Skipping record...", r.Name)
+			continue
+		}
+		desired[zone.Name] = append(desired[zone.Name], r)
+	}
 
-	return &googleDNSConsumer{
-		client:  client,
-		zones:   zones,
-		labels:  labels,
-		groupID: googleRecordGroupID,
-		project: googleProject,
-	}, nil
+	p := &plan.Plan{}
+	for _, z := range sortedZones(zones) {
+		currentRecords, err := d.currentRecords(z)
+		if err != nil {
+			return nil, err
+		}
+
+		current := d.planRecords(currentRecords)
+		log.Debugf("Current records in zone %s:", z.Name)
+		d.printRecords(current)
+
+		reportInvalidClaims(current, d.groupID, d.key)
+		zone := plan.NewZone(z.Name, z.DnsName, desired[z.Name], current, d.owns)
+		if zone.Suppressed = d.policy.Apply(zone.Changes); zone.Suppressed > 0 {
+			log.Infof("Suppressed %d changes in zone %s due to %s policy", zone.Suppressed, z.Name, d.policy)
+		}
+		zone.Changes = d.joinRecordSets(zone.Changes, current)
+		p.Zones = append(p.Zones, zone)
+	}
+
+	return p, nil
 }
 
-func (d *googleDNSConsumer) Sync(endpoints []*pkg.Endpoint) error {
-	currentRecords, err := d.currentRecords()
+// Apply applies previously planned changes, provided that none of the zones
+// has changed in the meantime.
+func (d *googleDNSConsumer) Apply(p *plan.Plan) error {
+	zones, err := d.managedZones()
 	if err != nil {
-		return err
+		return fmt.Errorf("Error getting managed zones in project %s: %v", d.project, err)
 	}
 
-	log.Debugln("Current records:")
-	d.printRecords(currentRecords)
+	for _, zone := range p.Zones {
+		z, exists := zones[zone.ID]
+		if !exists || z.DnsName != zone.Name {
+			return fmt.Errorf("Managed zone %s (%s) not found in project %s", zone.Name, zone.ID, d.project)
+		}
 
-	change := new(dns.Change)
+		currentRecords, err := d.currentRecords(z)
+		if err != nil {
+			return err
+		}
 
-	records := make(map[string][]string)
+		if plan.Fingerprint(d.planRecords(currentRecords)) != zone.Fingerprint {
+			return fmt.Errorf("Managed zone %s has changed since the plan was created", zone.Name)
+		}
+	}
 
-	for _, e := range endpoints {
-		record, exists := currentRecords[e.DNSName]
-
-		if !exists || exists && d.isResponsible(record.owner) {
-			records[e.DNSName] = append(records[e.DNSName], e.IP)
-		}
-	}
-
-	for dnsName, ips := range records {
-		change.Additions = append(change.Additions,
-			&dns.ResourceRecordSet{
-				Name:    dnsName,
-				Rrdatas: ips,
-				Ttl:     300,
-				Type:    "A",
-			},
-			&dns.ResourceRecordSet{
-				Name:    dnsName,
-				Rrdatas: d.labels,
-				Ttl:     300,
-				Type:    "TXT",
-			},
-		)
-	}
-
-	for _, r := range currentRecords {
-		if r.record != nil && d.isResponsible(r.owner) {
-			change.Deletions = append(change.Deletions,
-				&dns.ResourceRecordSet{
-					Name:    r.record.Name,
-					Rrdatas: r.record.Rrdatas,
-					Ttl:     r.record.Ttl,
-					Type:    r.record.Type,
-				},
-				&dns.ResourceRecordSet{
-					Name:    r.record.Name,
-					Rrdatas: d.labels,
-					Ttl:     r.record.Ttl,
-					Type:    "TXT",
-				},
-			)
-		}
-	}
-
-	err = d.applyChange(change)
+	err = d.apply(p)
 	if err != nil {
 		return fmt.Errorf("Error applying change for project %s: %v", d.project, err)
 	}
@@ -138,6 +251,241 @@ func (d *googleDNSConsumer) Sync(endpoints []*pkg.Endpoint) error {
 	return nil
 }
 
+func (d *googleDNSConsumer) apply(p *plan.Plan) error {
+	if err := d.maxDeletions.Check(p); err != nil {
+		return err
+	}
+
+	if d.dryRun {
+		log.Infof("[Google] Dry run, not applying changes to project %s:\n%s", d.project, p)
+		return nil
+	}
+
+	var failures []string
+	for _, zone := range p.Zones {
+		if zone.Changes.Empty() {
+			log.Debugf("Didn't submit change for zone %s (no changes)", zone.ID)
+			continue
+		}
+
+		if err := d.submitChange(zone.ID, d.change(zone.Changes)); err != nil {
+			log.Errorf("Unable to apply change to %s/%s: %v", d.project, zone.ID, err)
+			failures = append(failures, fmt.Sprintf("zone %s: %v", zone.ID, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// desiredRecords merges the IPs of all endpoints with the same DNS name into
+// a single A record. Endpoints with a hostname only are published as CNAME
+// records, which can't be merged, so the first endpoint of a name wins.
+// Endpoints with a routing policy become members of a single record with a
+// routing policy instead, one per resource for weighted and one per region
+// for latency based routing. Such records are identified by the set
+// identifier of this consumer, as they're merged with the members of other
+// clusters into a shared record set, see joinRecordSets.
+func (d *googleDNSConsumer) desiredRecords(endpoints []*pkg.Endpoint) []*plan.Record {
+	records := make(map[string]*plan.Record)
+	members := make(map[string]*plan.Record)
+	routed := make(map[string]bool)
+	resources := make(map[string]string)
+	desired := make([]*plan.Record, 0, len(endpoints))
+
+	for _, e := range endpoints {
+		name := pkg.SanitizeDNSName(e.DNSName)
+
+		recordType, target, ttl := "A", e.IP, defaultATTL
+		if e.IP == "" {
+			if e.Hostname == "" {
+				log.Warnf("Skipping endpoint %s without IP and hostname", e.DNSName)
+				continue
+			}
+			recordType, target, ttl = "CNAME", pkg.SanitizeDNSName(e.Hostname), defaultCNAMETTL
+		}
+
+		routing, err := endpointRoutingPolicy(e)
+		if err != nil {
+			log.Warnf("Skipping endpoint %s: %v", e.DNSName, err)
+			continue
+		}
+
+		record, exists := records[name]
+		if !exists {
+			record = &plan.Record{
+				Name: name,
+				Type: recordType,
+				TTL:  ttl,
+			}
+			records[name] = record
+			routed[name] = routing != nil
+			resources[name] = e.Resource
+			desired = append(desired, record)
+		}
+
+		if routed[name] != (routing != nil) {
+			log.Warnf("Skipping endpoint %s, records with and without a routing policy can't share a name", e.DNSName)
+			continue
+		}
+
+		if routing == nil {
+			if record.Type != recordType || recordType == "CNAME" && len(record.Targets) > 0 {
+				log.Warnf("Skipping endpoint %s pointing to %s, the record already points to %s", e.DNSName, target, strings.Join(record.Targets, ","))
+				continue
+			}
+			record.Targets = append(record.Targets, target)
+			continue
+		}
+
+		if record.Type != recordType || len(record.Members) > 0 && record.Members[0].Routing.Type != routing.Type {
+			log.Warnf("Skipping endpoint %s pointing to %s with %s routing, the record is a %s record with %s routing", e.DNSName, target, routing.Type, record.Type, record.Members[0].Routing.Type)
+			continue
+		}
+
+		key := name + " " + routing.Region
+		if routing.Type == pkg.WeightedRouting {
+			key = name + " " + e.Resource
+		}
+		member, exists := members[key]
+		if !exists {
+			member = &plan.Record{Routing: routing}
+			members[key] = member
+			record.Members = append(record.Members, member)
+		}
+
+		if recordType == "CNAME" && len(member.Targets) > 0 {
+			log.Warnf("Skipping endpoint %s pointing to %s, the member for %s already points to %s", e.DNSName, target, routing, strings.Join(member.Targets, ","))
+			continue
+		}
+		member.Targets = append(member.Targets, target)
+	}
+
+	for _, r := range desired {
+		if routed[r.Name] {
+			r.SetIdentifier = d.setIdentifier()
+		}
+		r.Owner = d.owner(r, resources[r.Name])
+	}
+	return desired
+}
+
+// planRecords converts the current records to plan records. Record sets
+// shared by several owners are split into one record per owner, see
+// splitRecordSet.
+func (d *googleDNSConsumer) planRecords(current map[string]*ownedRecord) []*plan.Record {
+	records := make([]*plan.Record, 0, len(current))
+
+	for name, r := range current {
+		record := &plan.Record{Name: name}
+		if r.record != nil {
+			record = d.recordToPlan(r.record)
+		}
+
+		if r.owner != nil {
+			if parts := d.splitRecordSet(record, r.owner); parts != nil {
+				records = append(records, parts...)
+				continue
+			}
+
+			record.OwnerRecord = d.recordToPlan(r.owner)
+			record.Owner = pkg.ParseOwner(r.owner.Rrdatas...)
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+// change converts the planned changes into a single change. Updated records
+// are deleted and added again.
+func (d *googleDNSConsumer) change(changes *plan.Changes) *recordChange {
+	change := new(recordChange)
+
+	for _, r := range changes.Create {
+		change.Additions = append(change.Additions, d.newRecords(r)...)
+	}
+
+	for _, u := range changes.Update {
+		change.Deletions = append(change.Deletions, d.existingRecords(u.Old)...)
+		change.Additions = append(change.Additions, d.newRecords(u.New)...)
+	}
+
+	for _, r := range changes.Delete {
+		change.Deletions = append(change.Deletions, d.existingRecords(r)...)
+	}
+
+	return change
+}
+
+// newRecords returns the record sets making up a desired record, including
+// its ownership record. Shared record sets come with the ownership record
+// listing all of their owners, which is left out if there are none.
+func (d *googleDNSConsumer) newRecords(r *plan.Record) []*recordSet {
+	records := []*recordSet{d.planToRecord(r)}
+	if r.OwnerRecord != nil {
+		records = append(records, d.planToRecord(r.OwnerRecord))
+	} else if r.Owner != nil {
+		records = append(records, d.planToOwnerRecord(r))
+	}
+	return records
+}
+
+// existingRecords returns the record sets making up a current record,
+// including its ownership record.
+func (d *googleDNSConsumer) existingRecords(r *plan.Record) []*recordSet {
+	var records []*recordSet
+	if r.Type != "" {
+		records = append(records, d.planToRecord(r))
+	}
+	if r.OwnerRecord != nil {
+		records = append(records, d.planToRecord(r.OwnerRecord))
+	}
+	return records
+}
+
+func (d *googleDNSConsumer) recordToPlan(record *recordSet) *plan.Record {
+	return &plan.Record{
+		Name:    record.Name,
+		Type:    record.Type,
+		Targets: record.Rrdatas,
+		TTL:     record.Ttl,
+		Members: routingMembers(record.RoutingPolicy),
+	}
+}
+
+func (d *googleDNSConsumer) planToRecord(r *plan.Record) *recordSet {
+	return &recordSet{
+		Name:          r.Name,
+		Rrdatas:       r.Targets,
+		Ttl:           r.TTL,
+		Type:          r.Type,
+		RoutingPolicy: membersRoutingPolicy(r.Members),
+	}
+}
+
+func (d *googleDNSConsumer) planToOwnerRecord(r *plan.Record) *recordSet {
+	return &recordSet{
+		Name:    pkg.OwnerRecordName(r.Name, r.Type),
+		Rrdatas: r.Owner.Labels(),
+		Ttl:     defaultTxtTTL,
+		Type:    "TXT",
+	}
+}
+
+// owns returns true if the record is owned by this consumer's group. Parts of
+// shared record sets are only owned by the cluster whose set identifier they
+// have.
+func (d *googleDNSConsumer) owns(r *plan.Record) bool {
+	if r.SetIdentifier != "" && r.SetIdentifier != d.setIdentifier() {
+		return false
+	}
+	return d.domainFilter.Match(r.Name) && ownedBy(r, d.groupID, d.key)
+}
+
 func (d *googleDNSConsumer) Consume(endpoints <-chan *pkg.Endpoint, errors chan<- error, done <-chan struct{}, wg *sync.WaitGroup) {
 	wg.Add(1)
 	defer wg.Done()
@@ -165,25 +513,79 @@ func (d *googleDNSConsumer) Consume(endpoints <-chan *pkg.Endpoint, errors chan<
 	}
 }
 
+// Process creates the record of the endpoint, or adds its target to the owned
+// record of the same name in a single change. Owned records it can't be added
+// to, e.g. of another type, are left to the next sync, which also removes the
+// targets of endpoints that are gone.
 func (d *googleDNSConsumer) Process(endpoint *pkg.Endpoint) error {
-	change := new(dns.Change)
-
-	change.Additions = []*dns.ResourceRecordSet{
-		{
-			Name:    endpoint.DNSName,
-			Rrdatas: []string{endpoint.IP},
-			Ttl:     300,
-			Type:    "A",
-		},
-		&dns.ResourceRecordSet{
-			Name:    endpoint.DNSName,
-			Rrdatas: d.labels,
-			Ttl:     300,
-			Type:    "TXT",
-		},
-	}
-
-	err := d.applyChange(change)
+	return d.processEndpoints([]*pkg.Endpoint{endpoint})
+}
+
+// processEndpoints processes endpoints sharing a DNS name at once, e.g. the
+// IPs of a resolved hostname. See Process.
+func (d *googleDNSConsumer) processEndpoints(endpoints []*pkg.Endpoint) error {
+	desired := d.desiredRecords(filterEndpoints(endpoints, d.domainFilter))
+	if len(desired) == 0 {
+		return nil
+	}
+
+	zones, err := d.managedZones()
+	if err != nil {
+		return fmt.Errorf("Error getting managed zones in project %s: %v", d.project, err)
+	}
+
+	name := desired[0].Name
+	z := zoneFor(zones, name)
+	if z == nil {
+		log.Warnf("Managed zone for endpoint: %s was not found. Skipping record...", name)
+		return nil
+	}
+
+	currentRecords, err := d.currentRecords(z)
+	if err != nil {
+		return err
+	}
+
+	var current []*plan.Record
+	for _, r := range d.planRecords(currentRecords) {
+		if r.Name == name {
+			current = append(current, r)
+		}
+	}
+	reportInvalidClaims(current, d.groupID, d.key)
+
+	for _, r := range current {
+		if r.Type == "" || !d.owns(r) {
+			continue
+		}
+		merged := mergeEndpointRecord(r, desired[0])
+		if merged == nil {
+			log.Debugf("Not updating record %s of type %s with endpoint %s, it's left to the next sync", name, r.Type, desired[0])
+			return nil
+		}
+		merged.Owner = d.owner(merged, r.Owner.Resource)
+		desired = []*plan.Record{merged}
+	}
+
+	changes := plan.Calculate(desired, current, d.owns)
+	d.policy.Apply(changes)
+	changes = d.joinRecordSets(changes, current)
+
+	if d.dryRun {
+		log.Infof("[Google] Dry run, not applying changes to zone %s:\n%s", z.Name, changes)
+		return nil
+	}
+
+	if changes.Empty() {
+		log.Debugf("Didn't submit change for record %s (no changes)", name)
+		return nil
+	}
+
+	err = d.submitChange(z.Name, d.change(changes))
+	if hasReason(err, "alreadyExists") {
+		log.Warnf("Record [name=%s] could not be created, another record with same name already exists", name)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("Error applying change for project %s: %v", d.project, err)
 	}
@@ -191,113 +593,137 @@ func (d *googleDNSConsumer) Process(endpoint *pkg.Endpoint) error {
 	return nil
 }
 
-func (d *googleDNSConsumer) applyChange(change *dns.Change) error {
-	if len(change.Additions) == 0 && len(change.Deletions) == 0 {
-		log.Infof("Didn't submit change (no changes)")
+// mergeEndpointRecord returns a copy of the current record which also points
+// to the targets of the desired record, members are matched by their routing
+// policy. It returns nil if the records can't be merged because they differ in
+// their type or routing policy, or because a CNAME record would get several
+// targets.
+func mergeEndpointRecord(current, desired *plan.Record) *plan.Record {
+	if current.Type != desired.Type || (len(current.Members) > 0) != (len(desired.Members) > 0) {
 		return nil
 	}
 
-	additions := make(map[string][]*dns.ResourceRecordSet)
-	deletions := make(map[string][]*dns.ResourceRecordSet)
-
-	for _, add := range change.Additions {
-		hostedZone := d.hostedZoneFor(add.Name)
-		additions[hostedZone] = append(additions[hostedZone], add)
-	}
-	for _, del := range change.Deletions {
-		hostedZone := d.hostedZoneFor(del.Name)
-		deletions[hostedZone] = append(deletions[hostedZone], del)
+	merged := *current
+	merged.OwnerRecord = nil
+	merged.Targets = mergeTargets(current.Targets, desired.Targets)
+	merged.Members = make([]*plan.Record, 0, len(current.Members)+len(desired.Members))
+	for _, m := range current.Members {
+		member := *m
+		merged.Members = append(merged.Members, &member)
 	}
 
-	changes := make(map[string]*dns.Change)
-	for _, z := range d.zones {
-		if len(additions[z.Name]) == 0 && len(deletions[z.Name]) == 0 {
-			log.Debugf("Didn't submit change for zone %s (no changes)", z.Name)
-			continue
+	for _, m := range desired.Members {
+		var match *plan.Record
+		for _, member := range merged.Members {
+			if member.Routing.Equal(m.Routing) {
+				match = member
+			}
 		}
-
-		changes[z.Name] = &dns.Change{
-			Additions: additions[z.Name],
-			Deletions: deletions[z.Name],
+		if match == nil {
+			if merged.Members[0].Routing.Type != m.Routing.Type {
+				return nil
+			}
+			match = &plan.Record{Routing: m.Routing}
+			merged.Members = append(merged.Members, match)
 		}
+		match.Targets = mergeTargets(match.Targets, m.Targets)
 	}
 
-	for z, c := range changes {
-		_, err := d.client.Changes.Create(d.project, z, c).Do()
-		if err != nil {
-			if strings.Contains(err.Error(), "alreadyExists") {
-				log.Warnf("Cannot update some DNS records (already exist)")
-				continue
+	if merged.Type == "CNAME" {
+		if len(merged.Targets) > 1 {
+			return nil
+		}
+		for _, m := range merged.Members {
+			if len(m.Targets) > 1 {
+				return nil
 			}
-			log.Errorf("Unable to create change for %s/%s: %v", d.project, z, err)
 		}
 	}
-
-	return nil
+	return &merged
 }
 
-func (d *googleDNSConsumer) currentRecords() (map[string]*ownedRecord, error) {
-	aggregatedRecords := make([]*dns.ResourceRecordSet, 0)
+// mergeTargets returns the targets of both lists without duplicates.
+func mergeTargets(current, desired []string) []string {
+	merged := append([]string(nil), current...)
+	for _, t := range desired {
+		found := false
+		for _, c := range current {
+			found = found || pkg.SanitizeDNSName(c) == pkg.SanitizeDNSName(t)
+		}
+		if !found {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
 
-	for _, z := range d.zones {
-		resp, err := d.client.ResourceRecordSets.List(d.project, z.Name).Do()
+func (d *googleDNSConsumer) currentRecords(zone *managedZone) (map[string]*ownedRecord, error) {
+	var rrsets []*recordSet
+	pageToken := ""
+	for {
+		page := &recordSetsPage{}
+		err := d.retry(func() error {
+			return d.getJSON(page, pageToken, "managedZones", zone.Name, "rrsets")
+		})
 		if err != nil {
-			return nil, fmt.Errorf("Error getting DNS records from %s/%s: %v", d.project, z.Name, err)
+			return nil, fmt.Errorf("Error getting DNS records from %s/%s: %v", d.project, zone.Name, err)
 		}
 
-		aggregatedRecords = append(aggregatedRecords, resp.Rrsets...)
+		log.Debugf("Getting a page of records of zone %s of length: %d", zone.Name, len(page.Rrsets))
+		rrsets = append(rrsets, page.Rrsets...)
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
 	}
 
 	records := make(map[string]*ownedRecord)
 
-	for _, r := range aggregatedRecords {
-		if r.Type == "A" || r.Type == "TXT" {
-			record, exists := records[r.Name]
+	for _, r := range rrsets {
+		name := r.Name
+		if r.Type == "TXT" {
+			name = pkg.OwnedRecordName(name)
+		}
+
+		if r.Type == "A" || r.Type == "CNAME" || r.Type == "TXT" {
+			record, exists := records[name]
 
 			if !exists {
 				record = &ownedRecord{}
 			}
 
 			switch r.Type {
-			case "A":
+			case "A", "CNAME":
 				record.record = r
 			case "TXT":
 				record.owner = r
 			}
 
-			records[r.Name] = record
+			records[name] = record
 		}
 	}
 
 	return records, nil
 }
 
-func (d *googleDNSConsumer) printRecords(records map[string]*ownedRecord) {
+func (d *googleDNSConsumer) printRecords(records []*plan.Record) {
 	for _, r := range records {
-		if r.record != nil && d.isResponsible(r.owner) {
-			log.Debugln(" ", r.record.Name, r.record.Type, r.record.Rrdatas)
-		}
-	}
-}
-
-func (d *googleDNSConsumer) hostedZoneFor(name string) string {
-	var matchName string
-	var matchID string
-	for zoneName, zone := range d.zones {
-		if strings.HasSuffix(name, zoneName) && len(zoneName) > len(matchName) { //get the longest match for the dns name
-			matchName = zoneName
-			matchID = zone.Name
+		if r.Type != "" && ownedBy(r, d.groupID, d.key) {
+			log.Debugln(" ", r.Name, r.Type, r.Targets)
 		}
 	}
-	return matchID
 }
 
-func (d *googleDNSConsumer) isResponsible(record *dns.ResourceRecordSet) bool {
-	return record != nil && d.labelsMatch(record.Rrdatas)
+func (d *googleDNSConsumer) owner(r *plan.Record, resource string) *pkg.Owner {
+	return newOwner(r, resource, d.groupID, d.cluster, d.key)
 }
 
-func (d *googleDNSConsumer) labelsMatch(labels []string) bool {
-	return len(labels) == 2 &&
-		strings.Trim(labels[0], `"`) == heritageLabel &&
-		strings.Trim(labels[1], `"`) == labelPrefix+d.groupID
+// setIdentifier returns the identifier of the records with a routing policy
+// created by this consumer, which allows every cluster to hold its own
+// members of a shared record set.
+func (d *googleDNSConsumer) setIdentifier() string {
+	if d.cluster != "" {
+		return d.cluster
+	}
+	return d.groupID
 }
@@ -0,0 +1,112 @@
+package consumers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/api/googleapi"
+)
+
+// The vendored Cloud DNS client predates the visibility and labels of managed
+// zones and the routing policies of record sets, so the API is used with plain
+// requests and the types below, which only hold the fields used by the
+// consumer.
+
+const googleDNSBasePath = "https://www.googleapis.com/dns/v1/projects/"
+
+type recordSet struct {
+	Name          string         `json:"name"`
+	Type          string         `json:"type"`
+	Ttl           int64          `json:"ttl,omitempty"`
+	Rrdatas       []string       `json:"rrdatas,omitempty"`
+	RoutingPolicy *routingPolicy `json:"routingPolicy,omitempty"`
+}
+
+type recordSetsPage struct {
+	Rrsets        []*recordSet `json:"rrsets"`
+	NextPageToken string       `json:"nextPageToken"`
+}
+
+// routingPolicy chooses between the targets of its items, either by their
+// weight or by the region closest to the client. Only one of both is set.
+type routingPolicy struct {
+	Wrr *wrrPolicy `json:"wrr,omitempty"`
+	Geo *geoPolicy `json:"geo,omitempty"`
+}
+
+type wrrPolicy struct {
+	Items []*wrrPolicyItem `json:"items"`
+}
+
+type wrrPolicyItem struct {
+	Weight  float64  `json:"weight"`
+	Rrdatas []string `json:"rrdatas"`
+}
+
+type geoPolicy struct {
+	Items []*geoPolicyItem `json:"items"`
+}
+
+type geoPolicyItem struct {
+	Location string   `json:"location"`
+	Rrdatas  []string `json:"rrdatas"`
+}
+
+// recordChange atomically deletes and adds record sets of a managed zone.
+type recordChange struct {
+	Id        string       `json:"id,omitempty"`
+	Status    string       `json:"status,omitempty"`
+	Additions []*recordSet `json:"additions,omitempty"`
+	Deletions []*recordSet `json:"deletions,omitempty"`
+}
+
+// getJSON requests the page of the resource of the project, given by its path
+// segments, and decodes the response into v.
+func (d *googleDNSConsumer) getJSON(v interface{}, pageToken string, resource ...string) error {
+	params := url.Values{"alt": {"json"}}
+	if pageToken != "" {
+		params.Set("pageToken", pageToken)
+	}
+
+	resp, err := d.httpClient.Get(d.resourceURL(resource...) + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	return decodeResponse(resp, v)
+}
+
+// postJSON posts the body to the resource of the project, given by its path
+// segments, and decodes the response into v.
+func (d *googleDNSConsumer) postJSON(body, v interface{}, resource ...string) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.httpClient.Post(d.resourceURL(resource...)+"?alt=json", "application/json", bytes.NewReader(encoded))
+	if err != nil {
+		return err
+	}
+	return decodeResponse(resp, v)
+}
+
+// resourceURL returns the URL of the resource of the project with escaped
+// path segments.
+func (d *googleDNSConsumer) resourceURL(resource ...string) string {
+	u := d.basePath + url.PathEscape(d.project)
+	for _, segment := range resource {
+		u += "/" + url.PathEscape(segment)
+	}
+	return u
+}
+
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,159 @@
+package consumers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"google.golang.org/api/googleapi"
+
+	"github.com/zalando-incubator/mate/pkg"
+)
+
+const (
+	maxChangeAdditions = 1000 // maximum number of record sets added by a single change
+	maxChangeDeletions = 1000 // maximum number of record sets deleted by a single change
+
+	defaultGoogleRetries      = 5
+	defaultGoogleBackoff      = time.Second
+	defaultGooglePollInterval = time.Second
+	defaultGoogleTimeout      = 5 * time.Minute
+
+	changeStatusDone = "done"
+)
+
+// submitChange applies the change to the managed zone, split into several
+// changes if it exceeds the limits of a single one, and waits for them to be
+// done. Additions and deletions of the same name are kept in the same change,
+// so that records are still replaced atomically.
+func (d *googleDNSConsumer) submitChange(zone string, change *recordChange) error {
+	changes := splitChange(change)
+	for i, c := range changes {
+		log.Debugf("Submitting change %d/%d with %d additions and %d deletions to zone %s", i+1, len(changes), len(c.Additions), len(c.Deletions), zone)
+
+		submitted := &recordChange{}
+		err := d.retry(func() error {
+			return d.postJSON(c, submitted, "managedZones", zone, "changes")
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := d.waitForChange(zone, submitted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForChange polls the status of the change until it's done.
+func (d *googleDNSConsumer) waitForChange(zone string, change *recordChange) error {
+	deadline := time.Now().Add(d.timeout)
+	for change.Status != changeStatusDone {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("change %s is still %s after %s", change.Id, change.Status, d.timeout)
+		}
+		time.Sleep(d.pollInterval)
+
+		id := change.Id
+		change = &recordChange{}
+		err := d.retry(func() error {
+			return d.getJSON(change, "", "managedZones", zone, "changes", id)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get the status of change %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// retry calls f until it succeeds, fails with an error that isn't worth
+// retrying or the retries are exhausted, doubling the delay between attempts.
+func (d *googleDNSConsumer) retry(f func() error) error {
+	backoff := d.backoff
+	for attempt := 0; ; attempt++ {
+		err := f()
+		if err == nil || attempt >= d.retries || !isRetryable(err) {
+			return err
+		}
+
+		log.Warnf("Retrying Cloud DNS request in %s: %v", backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// isRetryable returns true for errors caused by rate limits or on the side
+// of the API.
+func isRetryable(err error) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	if apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError {
+		return true
+	}
+	if apiErr.Code == http.StatusForbidden {
+		for _, e := range apiErr.Errors {
+			if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasReason returns true if the error was returned by the API for the given
+// reason, e.g. alreadyExists.
+func hasReason(err error, reason string) bool {
+	apiErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	for _, e := range apiErr.Errors {
+		if e.Reason == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// splitChange splits the change into changes within the limits of a single
+// one, keeping the additions and deletions of a name together. The TXT
+// records holding the ownership of CNAME records are kept with them.
+func splitChange(change *recordChange) []*recordChange {
+	var names []string
+	additions := map[string][]*recordSet{}
+	deletions := map[string][]*recordSet{}
+	for _, r := range change.Additions {
+		name := pkg.OwnedRecordName(r.Name)
+		if _, exists := additions[name]; !exists && deletions[name] == nil {
+			names = append(names, name)
+		}
+		additions[name] = append(additions[name], r)
+	}
+	for _, r := range change.Deletions {
+		name := pkg.OwnedRecordName(r.Name)
+		if _, exists := deletions[name]; !exists && additions[name] == nil {
+			names = append(names, name)
+		}
+		deletions[name] = append(deletions[name], r)
+	}
+
+	var changes []*recordChange
+	current := &recordChange{}
+	for _, name := range names {
+		exceeded := len(current.Additions)+len(additions[name]) > maxChangeAdditions || len(current.Deletions)+len(deletions[name]) > maxChangeDeletions
+		if exceeded && (len(current.Additions) > 0 || len(current.Deletions) > 0) {
+			changes = append(changes, current)
+			current = &recordChange{}
+		}
+		current.Additions = append(current.Additions, additions[name]...)
+		current.Deletions = append(current.Deletions, deletions[name]...)
+	}
+	if len(current.Additions) > 0 || len(current.Deletions) > 0 {
+		changes = append(changes, current)
+	}
+	return changes
+}
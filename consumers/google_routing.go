@@ -0,0 +1,84 @@
+package consumers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/zalando-incubator/mate/pkg"
+	"github.com/zalando-incubator/mate/plan"
+)
+
+// endpointRoutingPolicy returns the routing policy requested by the
+// annotations of the endpoint, nil if there is none. Cloud DNS supports
+// weighted round robin and routing to the closest region, which serves as
+// latency based routing.
+func endpointRoutingPolicy(e *pkg.Endpoint) (*pkg.RoutingPolicy, error) {
+	routing, err := pkg.ParseRoutingPolicy(e.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	if routing != nil && routing.Type != pkg.WeightedRouting && routing.Type != pkg.LatencyRouting {
+		return nil, fmt.Errorf("%s routing isn't supported by Cloud DNS", routing.Type)
+	}
+	return routing, nil
+}
+
+// itemIndexAttribute holds the position of a member among the items of the
+// routing policy, so that a shared record set can be put together again in
+// its original order, see joinParts.
+const itemIndexAttribute = "google/item-index"
+
+// routingMembers converts the items of the routing policy to members of a
+// record, nil if there is no policy.
+func routingMembers(p *routingPolicy) []*plan.Record {
+	if p == nil {
+		return nil
+	}
+
+	var members []*plan.Record
+	if p.Wrr != nil {
+		for _, item := range p.Wrr.Items {
+			members = append(members, &plan.Record{
+				Targets: item.Rrdatas,
+				Routing: &pkg.RoutingPolicy{Type: pkg.WeightedRouting, Weight: int64(math.Floor(item.Weight + 0.5))},
+			})
+		}
+	}
+	if p.Geo != nil {
+		for _, item := range p.Geo.Items {
+			members = append(members, &plan.Record{
+				Targets: item.Rrdatas,
+				Routing: &pkg.RoutingPolicy{Type: pkg.LatencyRouting, Region: item.Location},
+			})
+		}
+	}
+	for i, m := range members {
+		m.Attributes = map[string]string{itemIndexAttribute: strconv.Itoa(i)}
+	}
+	return members
+}
+
+// membersRoutingPolicy converts the members of a record to the items of a
+// routing policy, nil if there are none. All members share the type of their
+// routing policy.
+func membersRoutingPolicy(members []*plan.Record) *routingPolicy {
+	if len(members) == 0 {
+		return nil
+	}
+
+	if members[0].Routing.Type == pkg.WeightedRouting {
+		wrr := &wrrPolicy{}
+		for _, m := range members {
+			wrr.Items = append(wrr.Items, &wrrPolicyItem{Weight: float64(m.Routing.Weight), Rrdatas: m.Targets})
+		}
+		return &routingPolicy{Wrr: wrr}
+	}
+
+	geo := &geoPolicy{}
+	for _, m := range members {
+		geo.Items = append(geo.Items, &geoPolicyItem{Location: m.Routing.Region, Rrdatas: m.Targets})
+	}
+	return &routingPolicy{Geo: geo}
+}
@@ -0,0 +1,285 @@
+package consumers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/zalando-incubator/mate/pkg"
+	"github.com/zalando-incubator/mate/plan"
+)
+
+// Cloud DNS has a single record set per name and type, whose routing policy
+// holds the targets of all clusters. Such a record set is shared by listing
+// every owner in its ownership record, one value per owner along with the
+// keys of the members it holds. For planning, the record set is split into
+// one record per owner, identified by its cluster like the members of a
+// policy based record set on AWS, so that each cluster only changes its own
+// members. The changes are joined into changes of the whole record set again
+// before they are applied, see joinRecordSets.
+
+// splitRecordSet splits the record and its ownership record into one record
+// per owner listed in the ownership record, each with the members listed for
+// it. Members no owner lists are kept in a record without a set identifier
+// and owner. All parts refer to the whole ownership record. It returns nil if
+// the ownership record doesn't list the members of its owners, e.g. of
+// records owned as a whole.
+func (d *googleDNSConsumer) splitRecordSet(record *plan.Record, owner *recordSet) []*plan.Record {
+	ownerRecord := d.recordToPlan(owner)
+	unclaimed := append([]*plan.Record(nil), record.Members...)
+
+	var parts []*plan.Record
+	for _, value := range owner.Rrdatas {
+		o := pkg.ParseOwner(value)
+		if o == nil || len(o.Members) == 0 {
+			continue
+		}
+
+		part := &plan.Record{
+			Name:          record.Name,
+			Type:          record.Type,
+			TTL:           record.TTL,
+			SetIdentifier: ownerSetIdentifier(o),
+			Owner:         o,
+			OwnerRecord:   ownerRecord,
+		}
+		for _, key := range o.Members {
+			for i, m := range unclaimed {
+				if memberKey(m) == key {
+					part.Members = append(part.Members, m)
+					unclaimed = append(unclaimed[:i], unclaimed[i+1:]...)
+					break
+				}
+			}
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+	if len(unclaimed) > 0 {
+		parts = append(parts, &plan.Record{
+			Name:        record.Name,
+			Type:        record.Type,
+			TTL:         record.TTL,
+			Members:     unclaimed,
+			OwnerRecord: ownerRecord,
+		})
+	}
+	return parts
+}
+
+// joinRecordSets replaces the changes of the parts of shared record sets,
+// i.e. of records with a set identifier, with changes of the whole record
+// sets, which keep the members of the other parts. Changes of record sets
+// whose parts can't be joined, e.g. as one of them is owned as a whole or of
+// another type, are left out.
+func (d *googleDNSConsumer) joinRecordSets(changes *plan.Changes, current []*plan.Record) *plan.Changes {
+	shared := map[string]bool{}
+	for _, r := range changes.Create {
+		shared[r.Name] = shared[r.Name] || r.SetIdentifier != ""
+	}
+	for _, u := range changes.Update {
+		shared[u.Old.Name] = shared[u.Old.Name] || u.Old.SetIdentifier != "" || u.New.SetIdentifier != ""
+	}
+	for _, r := range changes.Delete {
+		shared[r.Name] = shared[r.Name] || r.SetIdentifier != ""
+	}
+
+	joined := &plan.Changes{}
+	added := map[string][]*plan.Record{}
+	replaced := map[*plan.Record]*plan.Record{} // current part -> desired part, nil if deleted
+	for _, r := range changes.Create {
+		if shared[r.Name] {
+			added[r.Name] = append(added[r.Name], r)
+		} else {
+			joined.Create = append(joined.Create, r)
+		}
+	}
+	for _, u := range changes.Update {
+		if shared[u.Old.Name] {
+			replaced[u.Old] = u.New
+		} else {
+			joined.Update = append(joined.Update, u)
+		}
+	}
+	for _, r := range changes.Delete {
+		if shared[r.Name] {
+			replaced[r] = nil
+		} else {
+			joined.Delete = append(joined.Delete, r)
+		}
+	}
+
+	names := make([]string, 0, len(shared))
+	for name, isShared := range shared {
+		if isShared {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var before, after []*plan.Record
+		for _, r := range current {
+			if r.Name != name {
+				continue
+			}
+			before = append(before, r)
+			if n, exists := replaced[r]; exists {
+				if n != nil {
+					after = append(after, n)
+				}
+				continue
+			}
+			after = append(after, r)
+		}
+		after = append(after, added[name]...)
+
+		if err := shareable(after); err != nil {
+			log.Warnf("Skipping changes of record %s: %v", name, err)
+			continue
+		}
+
+		old, desired := joinParts(before), joinParts(after)
+		if desired != nil && desired.Type == "" {
+			desired = nil
+		}
+		if desired != nil {
+			desired.Owner, desired.OwnerRecord = d.sharedOwners(desired, after)
+		}
+		switch {
+		case old == nil:
+			joined.Create = append(joined.Create, desired)
+		case desired == nil:
+			joined.Delete = append(joined.Delete, old)
+		default:
+			joined.Update = append(joined.Update, &plan.Update{Old: old, New: desired})
+		}
+	}
+
+	return joined
+}
+
+// joinParts joins the parts of a shared record set into a single record with
+// the members of all parts, the current members in their original order. The
+// current ownership record is taken over from the parts, which all refer to
+// the same. It returns nil if there are no parts.
+func joinParts(parts []*plan.Record) *plan.Record {
+	if len(parts) == 0 {
+		return nil
+	}
+
+	joined := &plan.Record{Name: parts[0].Name}
+	for _, p := range parts {
+		if p.Type != "" {
+			joined.Type, joined.TTL = p.Type, p.TTL
+			joined.Members = append(joined.Members, p.Members...)
+		}
+		if joined.OwnerRecord == nil {
+			joined.OwnerRecord = p.OwnerRecord
+		}
+	}
+
+	sort.SliceStable(joined.Members, func(i, j int) bool {
+		return itemIndex(joined.Members[i]) < itemIndex(joined.Members[j])
+	})
+	return joined
+}
+
+// itemIndex returns the position of the member in the current record set,
+// see routingMembers. Desired members come last.
+func itemIndex(m *plan.Record) int {
+	if index, err := strconv.Atoi(m.Attributes[itemIndexAttribute]); err == nil {
+		return index
+	}
+	return int(^uint(0) >> 1)
+}
+
+// sharedOwners returns the owner of this consumer's part of the joined record
+// and the ownership record listing the owners of all parts, nil if none of
+// them has an owner.
+func (d *googleDNSConsumer) sharedOwners(joined *plan.Record, parts []*plan.Record) (*pkg.Owner, *plan.Record) {
+	var owner *pkg.Owner
+	var values []string
+	for _, p := range parts {
+		if p.SetIdentifier == "" || p.Owner == nil {
+			continue
+		}
+		if p.SetIdentifier == d.setIdentifier() {
+			owner = p.Owner
+		}
+		values = append(values, ownerValue(p.Owner))
+	}
+
+	if len(values) == 0 {
+		return owner, nil
+	}
+	return owner, &plan.Record{
+		Name:    pkg.OwnerRecordName(joined.Name, joined.Type),
+		Type:    "TXT",
+		TTL:     defaultTxtTTL,
+		Targets: values,
+	}
+}
+
+// shareable returns an error if the parts can't be joined into a single record
+// set: if any of them is owned as a whole, if they differ in their type or
+// routing policy or if several members route the same region.
+func shareable(parts []*plan.Record) error {
+	var recordType, routingType string
+	regions := map[string]bool{}
+	for _, p := range parts {
+		if p.SetIdentifier == "" && p.Owner != nil {
+			return fmt.Errorf("the record is owned as a whole by group %s", p.Owner.GroupID)
+		}
+		if p.Type == "" {
+			continue
+		}
+		if len(p.Members) == 0 {
+			return fmt.Errorf("records with and without a routing policy can't share a name")
+		}
+
+		if recordType == "" {
+			recordType, routingType = p.Type, p.Members[0].Routing.Type
+		}
+		if p.Type != recordType {
+			return fmt.Errorf("%s and %s records can't share a name", recordType, p.Type)
+		}
+		for _, m := range p.Members {
+			if m.Routing.Type != routingType {
+				return fmt.Errorf("members with %s and %s routing can't share a record", routingType, m.Routing.Type)
+			}
+			if m.Routing.Type == pkg.LatencyRouting {
+				if regions[m.Routing.Region] {
+					return fmt.Errorf("several members route region %s", m.Routing.Region)
+				}
+				regions[m.Routing.Region] = true
+			}
+		}
+	}
+	return nil
+}
+
+// ownerSetIdentifier returns the set identifier of the part of a shared record
+// set held by the owner, see googleDNSConsumer.setIdentifier.
+func ownerSetIdentifier(o *pkg.Owner) string {
+	if o.Cluster != "" {
+		return o.Cluster
+	}
+	return o.GroupID
+}
+
+// ownerValue returns the ownership information as a single value of the
+// ownership record, with a quoted string per label.
+func ownerValue(o *pkg.Owner) string {
+	labels := o.Labels()
+	for i := range labels {
+		labels[i] = strconv.Quote(labels[i])
+	}
+	return strings.Join(labels, " ")
+}
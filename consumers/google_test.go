@@ -0,0 +1,692 @@
+package consumers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zalando-incubator/mate/pkg"
+	"github.com/zalando-incubator/mate/plan"
+)
+
+const testProject = "test-project"
+
+// fakeCloudDNS serves the parts of the Cloud DNS API used by the Google
+// consumer from memory.
+type fakeCloudDNS struct {
+	sync.Mutex
+	zones   []*managedZone
+	records map[string]map[string]*recordSet // zone -> name and type -> record
+	changes map[string][]*recordChange       // zone -> submitted changes
+
+	failures int             // number of changes to fail with a server error before accepting them
+	rejected map[string]bool // zones whose changes are rejected
+	pending  bool            // whether changes are pending until their status is requested
+	polls    int             // number of requests for the status of changes
+	pageSize int             // maximum number of zones and records per page, unlimited if zero
+
+	unavailable bool // whether listing zones fails
+}
+
+func newFakeCloudDNS(zones ...*managedZone) *fakeCloudDNS {
+	f := &fakeCloudDNS{
+		zones:   zones,
+		records: map[string]map[string]*recordSet{},
+		changes: map[string][]*recordChange{},
+	}
+	for _, z := range zones {
+		f.records[z.Name] = map[string]*recordSet{}
+	}
+	return f
+}
+
+func (f *fakeCloudDNS) add(zone string, records ...*recordSet) {
+	for _, r := range records {
+		f.records[zone][r.Name+" "+r.Type] = r
+	}
+}
+
+func (f *fakeCloudDNS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(path) < 2 || path[0] != testProject || path[1] != "managedZones" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(path) == 2 && r.Method == "GET":
+		if f.unavailable {
+			writeError(w, http.StatusBadRequest, "invalid", "zones unavailable")
+			return
+		}
+		start, end, next := f.page(len(f.zones), r.URL.Query().Get("pageToken"))
+		writeJSON(w, http.StatusOK, &managedZonesPage{ManagedZones: f.zones[start:end], NextPageToken: next})
+	case len(path) == 4 && path[3] == "rrsets" && r.Method == "GET":
+		records := f.list(path[2])
+		start, end, next := f.page(len(records), r.URL.Query().Get("pageToken"))
+		writeJSON(w, http.StatusOK, &recordSetsPage{Rrsets: records[start:end], NextPageToken: next})
+	case len(path) == 4 && path[3] == "changes" && r.Method == "POST":
+		if f.failures > 0 {
+			f.failures--
+			writeError(w, http.StatusServiceUnavailable, "backendError", "try again")
+			return
+		}
+		if f.rejected[path[2]] {
+			writeError(w, http.StatusBadRequest, "invalid", "change rejected")
+			return
+		}
+		change := &recordChange{}
+		if err := json.NewDecoder(r.Body).Decode(change); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid", err.Error())
+			return
+		}
+		status, reason := f.apply(path[2], change)
+		if status != http.StatusOK {
+			writeError(w, status, reason, fmt.Sprintf("change rejected: %s", reason))
+			return
+		}
+		change.Id = fmt.Sprintf("%d", len(f.changes[path[2]]))
+		change.Status = "done"
+		if f.pending {
+			change.Status = "pending"
+		}
+		writeJSON(w, http.StatusOK, change)
+	case len(path) == 5 && path[3] == "changes" && r.Method == "GET":
+		f.polls++
+		writeJSON(w, http.StatusOK, &recordChange{Id: path[4], Status: "done"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// page returns the range of the items on the page of the token and the token
+// of the next page.
+func (f *fakeCloudDNS) page(items int, pageToken string) (int, int, string) {
+	start, _ := strconv.Atoi(pageToken)
+	if f.pageSize == 0 || start+f.pageSize >= items {
+		return start, items, ""
+	}
+	return start, start + f.pageSize, strconv.Itoa(start + f.pageSize)
+}
+
+func (f *fakeCloudDNS) list(zone string) []*recordSet {
+	keys := make([]string, 0, len(f.records[zone]))
+	for key := range f.records[zone] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	records := make([]*recordSet, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, f.records[zone][key])
+	}
+	return records
+}
+
+// apply applies the change atomically like Cloud DNS does, rejecting it if a
+// deleted record doesn't match, an added record already exists or a CNAME
+// record would share its name with another record.
+func (f *fakeCloudDNS) apply(zone string, change *recordChange) (int, string) {
+	records := map[string]*recordSet{}
+	for key, r := range f.records[zone] {
+		records[key] = r
+	}
+
+	for _, del := range change.Deletions {
+		key := del.Name + " " + del.Type
+		existing, exists := records[key]
+		if !exists {
+			return http.StatusNotFound, "notFound"
+		}
+		if !reflect.DeepEqual(existing, del) {
+			return http.StatusPreconditionFailed, "conditionNotMet"
+		}
+		delete(records, key)
+	}
+
+	for _, add := range change.Additions {
+		key := add.Name + " " + add.Type
+		if _, exists := records[key]; exists {
+			return http.StatusConflict, "alreadyExists"
+		}
+		for _, r := range records {
+			if r.Name == add.Name && (r.Type == "CNAME" || add.Type == "CNAME") {
+				return http.StatusBadRequest, "cnameResourceRecordSetConflict"
+			}
+		}
+		records[key] = add
+	}
+
+	f.records[zone] = records
+	f.changes[zone] = append(f.changes[zone], change)
+	return http.StatusOK, ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, reason, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": message,
+			"errors":  []map[string]string{{"reason": reason, "message": message}},
+		},
+	})
+}
+
+func newTestGoogleConsumer(t *testing.T, f *fakeCloudDNS) (*googleDNSConsumer, func()) {
+	server := httptest.NewServer(f)
+
+	return &googleDNSConsumer{
+		basePath:            server.URL + "/",
+		httpClient:          http.DefaultClient,
+		groupID:             "test",
+		project:             testProject,
+		retries:             defaultGoogleRetries,
+		backoff:             time.Millisecond,
+		pollInterval:        time.Millisecond,
+		timeout:             time.Second,
+		zoneRefreshInterval: time.Minute,
+	}, server.Close
+}
+
+func testZone() *managedZone {
+	return &managedZone{Name: "example-org", DnsName: "example.org."}
+}
+
+func TestGoogleConsumerCNAME(t *testing.T) {
+	f := newFakeCloudDNS(testZone())
+	consumer, done := newTestGoogleConsumer(t, f)
+	defer done()
+
+	endpoints := []*pkg.Endpoint{{DNSName: "foo.example.org", Hostname: "foo-123.eu-central-1.elb.amazonaws.com"}}
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+
+	cname := f.records["example-org"]["foo.example.org. CNAME"]
+	if cname == nil || !reflect.DeepEqual(cname.Rrdatas, []string{"foo-123.eu-central-1.elb.amazonaws.com."}) {
+		t.Fatalf("expected a CNAME record pointing to the load balancer, got %v", f.list("example-org"))
+	}
+	if owner := f.records["example-org"]["_mate-cname.foo.example.org. TXT"]; owner == nil || pkg.ParseOwner(owner.Rrdatas...).GroupID != "test" {
+		t.Errorf("expected the CNAME record to be owned, got %v", owner)
+	}
+
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.changes["example-org"]) != 1 {
+		t.Errorf("expected no change for an unchanged CNAME record, got %d changes", len(f.changes["example-org"]))
+	}
+
+	endpoints[0].Hostname = "bar-456.eu-central-1.elb.amazonaws.com"
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	cname = f.records["example-org"]["foo.example.org. CNAME"]
+	if cname == nil || !reflect.DeepEqual(cname.Rrdatas, []string{"bar-456.eu-central-1.elb.amazonaws.com."}) {
+		t.Errorf("expected the CNAME record to follow the load balancer, got %v", f.list("example-org"))
+	}
+
+	endpoints[0].Hostname, endpoints[0].IP = "", "1.2.3.4"
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	records := f.list("example-org")
+	if len(records) != 2 || records[0].Type != "A" || records[1].Type != "TXT" || records[1].Name != "foo.example.org." {
+		t.Errorf("expected the CNAME record to be replaced by an A record, got %v", records)
+	}
+}
+
+func TestGoogleConsumerRetries(t *testing.T) {
+	f := newFakeCloudDNS(testZone())
+	f.failures = 2
+	f.pending = true
+	consumer, done := newTestGoogleConsumer(t, f)
+	defer done()
+
+	if err := consumer.Sync([]*pkg.Endpoint{{DNSName: "foo.example.org", IP: "1.2.3.4"}}); err != nil {
+		t.Fatalf("expected the change to be retried, got %v", err)
+	}
+	if len(f.changes["example-org"]) != 1 || f.records["example-org"]["foo.example.org. A"] == nil {
+		t.Errorf("expected the record to be created, got %v", f.list("example-org"))
+	}
+	if f.polls == 0 {
+		t.Error("expected the status of the pending change to be polled")
+	}
+}
+
+func TestGoogleConsumerReportsErrors(t *testing.T) {
+	f := newFakeCloudDNS(testZone(), &managedZone{Name: "example-com", DnsName: "example.com."})
+	f.rejected = map[string]bool{"example-org": true}
+	consumer, done := newTestGoogleConsumer(t, f)
+	defer done()
+
+	err := consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "foo.example.org", IP: "1.2.3.4"},
+		{DNSName: "foo.example.com", IP: "1.2.3.4"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "example-org") {
+		t.Errorf("expected the failed change of zone example-org to be reported, got %v", err)
+	}
+	if f.records["example-com"]["foo.example.com. A"] == nil {
+		t.Error("expected the change of the other zone to be applied")
+	}
+
+	f.rejected = nil
+	f.failures = defaultGoogleRetries + 1
+	if err := consumer.Sync([]*pkg.Endpoint{{DNSName: "bar.example.com", IP: "1.2.3.4"}}); err == nil {
+		t.Error("expected an error once the retries are exhausted")
+	}
+}
+
+func TestGoogleConsumerProcess(t *testing.T) {
+	f := newFakeCloudDNS(testZone())
+	f.add("example-org",
+		&recordSet{Name: "bar.example.org.", Type: "A", Ttl: 300, Rrdatas: []string{"9.9.9.9"}},
+		&recordSet{Name: "baz.example.org.", Type: "A", Ttl: 300, Rrdatas: []string{"8.8.8.8"}},
+	)
+	consumer, done := newTestGoogleConsumer(t, f)
+	defer done()
+
+	endpoint := &pkg.Endpoint{DNSName: "foo.example.org", IP: "1.2.3.4"}
+	if err := consumer.Process(endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if err := consumer.Process(endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.changes["example-org"]) != 1 {
+		t.Fatalf("expected a single change creating the record, got %d changes", len(f.changes["example-org"]))
+	}
+
+	other := &pkg.Endpoint{DNSName: "foo.example.org", IP: "5.6.7.8", Resource: "service/default/other"}
+	if err := consumer.Process(other); err != nil {
+		t.Fatalf("expected the record to be updated, got %v", err)
+	}
+	if len(f.changes["example-org"]) != 2 {
+		t.Fatalf("expected a single change updating the record, got %d changes", len(f.changes["example-org"]))
+	}
+	change := f.changes["example-org"][1]
+	if len(change.Deletions) != 2 || len(change.Additions) != 2 {
+		t.Errorf("expected the record and its owner to be replaced, got %d deletions and %d additions", len(change.Deletions), len(change.Additions))
+	}
+	if a := f.records["example-org"]["foo.example.org. A"]; a == nil || !reflect.DeepEqual(a.Rrdatas, []string{"1.2.3.4", "5.6.7.8"}) {
+		t.Errorf("expected the record to point to the IPs of both endpoints, got %v", a)
+	}
+	if f.records["example-org"]["baz.example.org. A"] == nil {
+		t.Error("expected records of other names to be left alone")
+	}
+
+	if err := consumer.Process(&pkg.Endpoint{DNSName: "foo.example.org", Hostname: "lb.example.org"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.changes["example-org"]) != 2 || f.records["example-org"]["foo.example.org. A"] == nil {
+		t.Errorf("expected a record of another type to be left to the next sync, got %d changes", len(f.changes["example-org"]))
+	}
+
+	if err := consumer.Process(&pkg.Endpoint{DNSName: "bar.example.org", IP: "1.2.3.4"}); err != nil {
+		t.Fatal(err)
+	}
+	if a := f.records["example-org"]["bar.example.org. A"]; !reflect.DeepEqual(a.Rrdatas, []string{"9.9.9.9"}) {
+		t.Errorf("expected the unowned record to be left alone, got %v", a)
+	}
+}
+
+func TestMergeEndpointRecord(t *testing.T) {
+	weighted := func(weight int64, targets ...string) *plan.Record {
+		return &plan.Record{Targets: targets, Routing: &pkg.RoutingPolicy{Type: pkg.WeightedRouting, Weight: weight}}
+	}
+	latency := func(region string, targets ...string) *plan.Record {
+		return &plan.Record{Targets: targets, Routing: &pkg.RoutingPolicy{Type: pkg.LatencyRouting, Region: region}}
+	}
+
+	for _, test := range []struct {
+		current, desired, expected *plan.Record
+	}{
+		{
+			&plan.Record{Type: "A", Targets: []string{"1.2.3.4"}},
+			&plan.Record{Type: "A", Targets: []string{"1.2.3.4"}},
+			&plan.Record{Type: "A", Targets: []string{"1.2.3.4"}},
+		},
+		{
+			&plan.Record{Type: "A", Targets: []string{"1.2.3.4"}},
+			&plan.Record{Type: "A", Targets: []string{"5.6.7.8"}},
+			&plan.Record{Type: "A", Targets: []string{"1.2.3.4", "5.6.7.8"}},
+		},
+		{
+			&plan.Record{Type: "A", Members: []*plan.Record{weighted(1, "1.2.3.4")}},
+			&plan.Record{Type: "A", Members: []*plan.Record{weighted(1, "5.6.7.8"), weighted(2, "9.9.9.9")}},
+			&plan.Record{Type: "A", Members: []*plan.Record{weighted(1, "1.2.3.4", "5.6.7.8"), weighted(2, "9.9.9.9")}},
+		},
+		{
+			&plan.Record{Type: "CNAME", Targets: []string{"foo.example.org."}},
+			&plan.Record{Type: "CNAME", Targets: []string{"bar.example.org."}},
+			nil,
+		},
+		{
+			&plan.Record{Type: "A", Targets: []string{"1.2.3.4"}},
+			&plan.Record{Type: "CNAME", Targets: []string{"bar.example.org."}},
+			nil,
+		},
+		{
+			&plan.Record{Type: "A", Targets: []string{"1.2.3.4"}},
+			&plan.Record{Type: "A", Members: []*plan.Record{weighted(1, "5.6.7.8")}},
+			nil,
+		},
+		{
+			&plan.Record{Type: "A", Members: []*plan.Record{latency("europe-west1", "1.2.3.4")}},
+			&plan.Record{Type: "A", Members: []*plan.Record{weighted(1, "5.6.7.8")}},
+			nil,
+		},
+	} {
+		merged := mergeEndpointRecord(test.current, test.desired)
+		if test.expected == nil && merged != nil || test.expected != nil && (merged == nil || !merged.Equal(test.expected)) {
+			t.Errorf("mergeEndpointRecord(%v, %v) => %v, want %v", test.current, test.desired, merged, test.expected)
+		}
+	}
+}
+
+func TestGoogleConsumerPagination(t *testing.T) {
+	f := newFakeCloudDNS(&managedZone{Name: "example-com", DnsName: "example.com."}, testZone())
+	consumer, done := newTestGoogleConsumer(t, f)
+	defer done()
+
+	var endpoints []*pkg.Endpoint
+	for i := 0; i < 5; i++ {
+		endpoints = append(endpoints, &pkg.Endpoint{DNSName: fmt.Sprintf("foo-%d.example.org", i), IP: "1.2.3.4"})
+	}
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+
+	f.pageSize = 1
+	consumer.zoneRefreshInterval = 0
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.changes["example-org"]) != 1 {
+		t.Errorf("expected the records on all pages to be found, got %d changes", len(f.changes["example-org"]))
+	}
+
+	endpoints[4].IP = "5.6.7.8"
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if a := f.records["example-org"]["foo-4.example.org. A"]; a == nil || !reflect.DeepEqual(a.Rrdatas, []string{"5.6.7.8"}) {
+		t.Errorf("expected the record on the last page to be updated, got %v", a)
+	}
+}
+
+func TestGoogleConsumerZoneSelection(t *testing.T) {
+	f := newFakeCloudDNS(
+		&managedZone{Name: "public", DnsName: "example.org.", Labels: map[string]string{"team": "foo"}},
+		&managedZone{Name: "private", DnsName: "example.org.", Visibility: "private", Labels: map[string]string{"team": "foo"}},
+		&managedZone{Name: "other-team", DnsName: "other.example.org.", Labels: map[string]string{"team": "bar"}},
+	)
+	consumer, done := newTestGoogleConsumer(t, f)
+	defer done()
+
+	for _, test := range []struct {
+		names      []string
+		labels     map[string]string
+		visibility string
+		expected   []string
+	}{
+		{expected: []string{"other-team", "private", "public"}},
+		{names: []string{"private", "other-team"}, expected: []string{"other-team", "private"}},
+		{labels: map[string]string{"team": "foo"}, expected: []string{"private", "public"}},
+		{labels: map[string]string{"team": "foo", "env": "test"}, expected: []string{}},
+		{visibility: "public", expected: []string{"other-team", "public"}},
+		{visibility: "private", labels: map[string]string{"team": "foo"}, expected: []string{"private"}},
+	} {
+		consumer.zoneNames, consumer.zoneLabels, consumer.zoneVisibility = test.names, test.labels, test.visibility
+		consumer.zones = nil
+
+		zones, err := consumer.managedZones()
+		if err != nil {
+			t.Fatal(err)
+		}
+		selected := []string{}
+		for _, z := range sortedZones(zones) {
+			selected = append(selected, z.Name)
+		}
+		sort.Strings(selected)
+		if !reflect.DeepEqual(selected, test.expected) {
+			t.Errorf("expected zones %v to be selected by %v, %v and %q, got %v", test.expected, test.names, test.labels, test.visibility, selected)
+		}
+	}
+
+	consumer.zoneNames, consumer.zoneLabels, consumer.zoneVisibility, consumer.zones = nil, nil, "", nil
+	if err := consumer.Sync([]*pkg.Endpoint{{DNSName: "foo.example.org", IP: "1.2.3.4"}, {DNSName: "foo.other.example.org", IP: "1.2.3.4"}}); err != nil {
+		t.Fatal(err)
+	}
+	if f.records["public"]["foo.example.org. A"] == nil || f.records["private"]["foo.example.org. A"] != nil {
+		t.Error("expected the record to be created in the public zone of the same name")
+	}
+	if f.records["other-team"]["foo.other.example.org. A"] == nil {
+		t.Error("expected the record to be created in the zone with the longest matching name")
+	}
+}
+
+func TestGoogleConsumerZoneRefresh(t *testing.T) {
+	f := newFakeCloudDNS(testZone())
+	consumer, done := newTestGoogleConsumer(t, f)
+	defer done()
+
+	endpoints := []*pkg.Endpoint{{DNSName: "foo.example.org", IP: "1.2.3.4"}, {DNSName: "foo.example.com", IP: "1.2.3.4"}}
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Lock()
+	zone := &managedZone{Name: "example-com", DnsName: "example.com."}
+	f.zones = append(f.zones, zone)
+	f.records[zone.Name] = map[string]*recordSet{}
+	f.Unlock()
+
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.changes["example-com"]) != 0 {
+		t.Error("expected the zones not to be listed again before the refresh interval has passed")
+	}
+
+	consumer.zonesRefreshed = time.Now().Add(-consumer.zoneRefreshInterval)
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if f.records["example-com"]["foo.example.com. A"] == nil {
+		t.Error("expected the record to be created in the new zone")
+	}
+
+	f.unavailable = true
+	consumer.zonesRefreshed = time.Time{}
+	if _, err := consumer.managedZones(); err != nil {
+		t.Errorf("expected the previous zones to be kept if they can't be listed, got %v", err)
+	}
+}
+
+func TestGoogleConsumerRoutingPolicies(t *testing.T) {
+	f := newFakeCloudDNS(testZone())
+	consumer, done := newTestGoogleConsumer(t, f)
+	defer done()
+
+	weighted := func(weight string) map[string]string {
+		return map[string]string{pkg.RoutingPolicyAnnotation: "weighted", pkg.RoutingWeightAnnotation: weight}
+	}
+	latency := func(region string) map[string]string {
+		return map[string]string{pkg.RoutingPolicyAnnotation: "latency", pkg.RoutingRegionAnnotation: region}
+	}
+	endpoints := []*pkg.Endpoint{
+		{DNSName: "foo.example.org", IP: "1.1.1.1", Resource: "service/default/a", Annotations: weighted("10")},
+		{DNSName: "foo.example.org", IP: "1.1.1.2", Resource: "service/default/a", Annotations: weighted("10")},
+		{DNSName: "foo.example.org", IP: "2.2.2.2", Resource: "service/default/b", Annotations: weighted("20")},
+		{DNSName: "foo.example.org", IP: "3.3.3.3", Resource: "service/default/c"},
+		{DNSName: "bar.example.org", Hostname: "bar.eu.example.net", Resource: "ingress/default/eu", Annotations: latency("europe-west1")},
+		{DNSName: "bar.example.org", Hostname: "bar.us.example.net", Resource: "ingress/default/us", Annotations: latency("us-east1")},
+		{DNSName: "baz.example.org", IP: "4.4.4.4", Annotations: map[string]string{pkg.RoutingPolicyAnnotation: "geolocation", pkg.RoutingContinentAnnotation: "EU"}},
+	}
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &routingPolicy{Wrr: &wrrPolicy{Items: []*wrrPolicyItem{
+		{Weight: 10, Rrdatas: []string{"1.1.1.1", "1.1.1.2"}},
+		{Weight: 20, Rrdatas: []string{"2.2.2.2"}},
+	}}}
+	if r := f.records["example-org"]["foo.example.org. A"]; r == nil || len(r.Rrdatas) != 0 || !reflect.DeepEqual(r.RoutingPolicy, expected) {
+		t.Errorf("expected a weighted record with an item per resource, got %v", r)
+	}
+
+	expected = &routingPolicy{Geo: &geoPolicy{Items: []*geoPolicyItem{
+		{Location: "europe-west1", Rrdatas: []string{"bar.eu.example.net."}},
+		{Location: "us-east1", Rrdatas: []string{"bar.us.example.net."}},
+	}}}
+	if r := f.records["example-org"]["bar.example.org. CNAME"]; r == nil || !reflect.DeepEqual(r.RoutingPolicy, expected) {
+		t.Errorf("expected a geo record with an item per region, got %v", r)
+	}
+
+	if f.records["example-org"]["baz.example.org. A"] != nil {
+		t.Error("expected the endpoint with an unsupported routing policy to be skipped")
+	}
+
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.changes["example-org"]) != 1 {
+		t.Fatalf("expected no change for unchanged routing policies, got %d changes", len(f.changes["example-org"]))
+	}
+
+	endpoints[2].Annotations = weighted("0")
+	if err := consumer.Sync(endpoints); err != nil {
+		t.Fatal(err)
+	}
+	if r := f.records["example-org"]["foo.example.org. A"]; r.RoutingPolicy.Wrr.Items[1].Weight != 0 {
+		t.Errorf("expected the weight to be updated, got %v", r.RoutingPolicy.Wrr.Items[1])
+	}
+}
+
+func TestGoogleConsumerSharedRoutingPolicies(t *testing.T) {
+	f := newFakeCloudDNS(testZone())
+	a, doneA := newTestGoogleConsumer(t, f)
+	defer doneA()
+	b, doneB := newTestGoogleConsumer(t, f)
+	defer doneB()
+	a.cluster, b.cluster = "a", "b"
+
+	weighted := func(ip, weight string) []*pkg.Endpoint {
+		return []*pkg.Endpoint{{DNSName: "foo.example.org", IP: ip, Resource: "service/default/foo", Annotations: map[string]string{pkg.RoutingPolicyAnnotation: "weighted", pkg.RoutingWeightAnnotation: weight}}}
+	}
+	items := func() []*wrrPolicyItem {
+		r := f.records["example-org"]["foo.example.org. A"]
+		if r == nil || r.RoutingPolicy == nil {
+			return nil
+		}
+		return r.RoutingPolicy.Wrr.Items
+	}
+	owners := func() int {
+		if r := f.records["example-org"]["foo.example.org. TXT"]; r != nil {
+			return len(r.Rrdatas)
+		}
+		return 0
+	}
+
+	if err := a.Sync(weighted("1.1.1.1", "10")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Sync(weighted("2.2.2.2", "20")); err != nil {
+		t.Fatal(err)
+	}
+	expected := []*wrrPolicyItem{{Weight: 10, Rrdatas: []string{"1.1.1.1"}}, {Weight: 20, Rrdatas: []string{"2.2.2.2"}}}
+	if !reflect.DeepEqual(items(), expected) || owners() != 2 {
+		t.Fatalf("expected the record set to hold the items of both clusters along with both owners, got %v and %d owners", items(), owners())
+	}
+
+	if err := a.Sync(weighted("1.1.1.1", "10")); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.changes["example-org"]) != 2 {
+		t.Errorf("expected no change for unchanged items, got %d changes", len(f.changes["example-org"]))
+	}
+
+	if err := b.Sync(weighted("2.2.2.2", "30")); err != nil {
+		t.Fatal(err)
+	}
+	expected = []*wrrPolicyItem{{Weight: 10, Rrdatas: []string{"1.1.1.1"}}, {Weight: 30, Rrdatas: []string{"2.2.2.2"}}}
+	if !reflect.DeepEqual(items(), expected) {
+		t.Errorf("expected only the item of the cluster to be updated, got %v", items())
+	}
+
+	if err := a.Process(weighted("1.1.1.2", "40")[0]); err != nil {
+		t.Fatal(err)
+	}
+	expected = append(expected, &wrrPolicyItem{Weight: 40, Rrdatas: []string{"1.1.1.2"}})
+	if !reflect.DeepEqual(items(), expected) || owners() != 2 {
+		t.Errorf("expected the processed endpoint to be added to the items of the cluster, got %v and %d owners", items(), owners())
+	}
+
+	if err := a.Sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	expected = []*wrrPolicyItem{{Weight: 30, Rrdatas: []string{"2.2.2.2"}}}
+	if !reflect.DeepEqual(items(), expected) || owners() != 1 {
+		t.Errorf("expected the items of the other cluster to be kept, got %v and %d owners", items(), owners())
+	}
+
+	if err := b.Sync(nil); err != nil {
+		t.Fatal(err)
+	}
+	if items() != nil || owners() != 0 {
+		t.Errorf("expected the record set to be deleted along with its last item, got %v and %d owners", items(), owners())
+	}
+
+	whole := &pkg.Owner{GroupID: "other"}
+	f.add("example-org",
+		&recordSet{Name: "foo.example.org.", Type: "A", Ttl: defaultATTL, RoutingPolicy: &routingPolicy{Wrr: &wrrPolicy{Items: []*wrrPolicyItem{{Weight: 10, Rrdatas: []string{"3.3.3.3"}}}}}},
+		&recordSet{Name: "foo.example.org.", Type: "TXT", Ttl: defaultTxtTTL, Rrdatas: whole.Labels()},
+	)
+	if err := a.Sync(weighted("1.1.1.1", "10")); err != nil {
+		t.Fatal(err)
+	}
+	expected = []*wrrPolicyItem{{Weight: 10, Rrdatas: []string{"3.3.3.3"}}}
+	if !reflect.DeepEqual(items(), expected) {
+		t.Errorf("expected a record set owned as a whole by another group to be left alone, got %v", items())
+	}
+}
+
+func TestSplitChange(t *testing.T) {
+	change := &recordChange{}
+	for i := 0; i < 600; i++ {
+		name := fmt.Sprintf("foo-%d.example.org.", i)
+		change.Additions = append(change.Additions, &recordSet{Name: name, Type: "A"}, &recordSet{Name: name, Type: "TXT"})
+	}
+	change.Deletions = append(change.Deletions, &recordSet{Name: "foo-599.example.org.", Type: "A"})
+	change.Additions = append(change.Additions, &recordSet{Name: "_mate-cname.foo-599.example.org.", Type: "TXT"})
+
+	changes := splitChange(change)
+	if len(changes) != 2 {
+		t.Fatalf("expected two changes, got %d", len(changes))
+	}
+	if len(changes[0].Additions) != 1000 || len(changes[0].Deletions) != 0 {
+		t.Errorf("expected the first change to be filled up to the limit, got %d additions and %d deletions", len(changes[0].Additions), len(changes[0].Deletions))
+	}
+	if len(changes[1].Additions) != 201 || len(changes[1].Deletions) != 1 {
+		t.Errorf("expected the records of a name to be kept together, got %d additions and %d deletions", len(changes[1].Additions), len(changes[1].Deletions))
+	}
+}
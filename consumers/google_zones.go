@@ -0,0 +1,159 @@
+package consumers
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	defaultGoogleZoneRefreshInterval = time.Minute
+	publicZoneVisibility             = "public"
+)
+
+// managedZone is a Cloud DNS managed zone along with its visibility and
+// labels.
+type managedZone struct {
+	Name       string            `json:"name"`
+	DnsName    string            `json:"dnsName"`
+	Visibility string            `json:"visibility,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+type managedZonesPage struct {
+	ManagedZones  []*managedZone `json:"managedZones"`
+	NextPageToken string         `json:"nextPageToken"`
+}
+
+// managedZones returns the selected managed zones mapped by their name,
+// listing them again once the refresh interval has passed. The previous zones
+// are kept if listing them fails. The returned map must not be modified.
+func (d *googleDNSConsumer) managedZones() (map[string]*managedZone, error) {
+	d.zonesMu.Lock()
+	defer d.zonesMu.Unlock()
+
+	if d.zones != nil && time.Since(d.zonesRefreshed) < d.zoneRefreshInterval {
+		return d.zones, nil
+	}
+
+	listed, err := d.listManagedZones()
+	if err != nil {
+		if d.zones == nil {
+			return nil, err
+		}
+		log.Warnf("Error refreshing managed zones in project %s, using the previous ones: %v", d.project, err)
+		return d.zones, nil
+	}
+
+	zones := make(map[string]*managedZone)
+	for _, z := range listed {
+		if d.selectZone(z) {
+			zones[z.Name] = z
+		}
+	}
+
+	for name := range zones {
+		if _, exists := d.zones[name]; !exists {
+			log.Infof("Managing zone %s (%s)", name, zones[name].DnsName)
+		}
+	}
+	for name := range d.zones {
+		if _, exists := zones[name]; !exists {
+			log.Infof("No longer managing zone %s (%s)", name, d.zones[name].DnsName)
+		}
+	}
+
+	d.zones, d.zonesRefreshed = zones, time.Now()
+	return zones, nil
+}
+
+// listManagedZones returns all managed zones of the project, following the
+// pages of the listing.
+func (d *googleDNSConsumer) listManagedZones() ([]*managedZone, error) {
+	var zones []*managedZone
+	pageToken := ""
+	for {
+		page := &managedZonesPage{}
+		err := d.retry(func() error {
+			return d.getJSON(page, pageToken, "managedZones")
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		zones = append(zones, page.ManagedZones...)
+		if page.NextPageToken == "" {
+			return zones, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// selectZone returns true if the zone is matched by the domain filter and the
+// configured zone names, labels and visibility.
+func (d *googleDNSConsumer) selectZone(z *managedZone) bool {
+	if !d.domainFilter.MatchZone(z.DnsName) {
+		return false
+	}
+
+	if len(d.zoneNames) > 0 {
+		found := false
+		for _, name := range d.zoneNames {
+			found = found || name == z.Name
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for key, value := range d.zoneLabels {
+		if label, exists := z.Labels[key]; !exists || label != value {
+			return false
+		}
+	}
+
+	return d.zoneVisibility == "" || d.zoneVisibility == zoneVisibility(z)
+}
+
+// zoneVisibility returns the visibility of the zone, zones without one are
+// public.
+func zoneVisibility(z *managedZone) string {
+	if z.Visibility == "" {
+		return publicZoneVisibility
+	}
+	return z.Visibility
+}
+
+// zoneFor returns the zone with the longest DNS name the name belongs to, nil
+// if there is none. Of zones with the same DNS name, e.g. in split-horizon
+// setups, public zones are preferred.
+func zoneFor(zones map[string]*managedZone, name string) *managedZone {
+	var match *managedZone
+	for _, z := range sortedZones(zones) {
+		if name != z.DnsName && !strings.HasSuffix(name, "."+z.DnsName) {
+			continue
+		}
+		if match == nil || len(z.DnsName) > len(match.DnsName) ||
+			z.DnsName == match.DnsName && zoneVisibility(z) == publicZoneVisibility && zoneVisibility(match) != publicZoneVisibility {
+			match = z
+		}
+	}
+	return match
+}
+
+// sortedZones returns the zones sorted by their DNS name and name.
+func sortedZones(zones map[string]*managedZone) []*managedZone {
+	sorted := make([]*managedZone, 0, len(zones))
+	for _, z := range zones {
+		sorted = append(sorted, z)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].DnsName != sorted[j].DnsName {
+			return sorted[i].DnsName < sorted[j].DnsName
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,93 @@
+package consumers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/zalando-incubator/mate/pkg"
+	"github.com/zalando-incubator/mate/plan"
+)
+
+// newOwner returns the ownership information for the record created on
+// behalf of the given group, listing the keys of its members, if any. It's
+// signed if a key is given.
+func newOwner(r *plan.Record, resource, groupID, cluster string, key []byte) *pkg.Owner {
+	owner := &pkg.Owner{
+		GroupID:  groupID,
+		Resource: resource,
+		Cluster:  cluster,
+		Updated:  time.Now(),
+	}
+	for _, m := range r.Members {
+		owner.Members = append(owner.Members, memberKey(m))
+	}
+	if len(key) > 0 {
+		owner.Sign(r.Name, r.Type, r.SetIdentifier, key)
+	}
+	return owner
+}
+
+// memberKey identifies a member of a record by its routing policy and
+// targets, so that the members of a shared record set can be attributed to
+// their owners.
+func memberKey(m *plan.Record) string {
+	targets := make([]string, 0, len(m.Targets))
+	for _, t := range m.Targets {
+		targets = append(targets, pkg.SanitizeDNSName(t))
+	}
+	sort.Strings(targets)
+
+	routing := m.Routing.Region
+	if m.Routing.Type == pkg.WeightedRouting {
+		routing = strconv.FormatInt(m.Routing.Weight, 10)
+	}
+	hash := sha256.Sum256([]byte(m.Routing.Type + " " + routing + " " + strings.Join(targets, ",")))
+	return hex.EncodeToString(hash[:])[:8]
+}
+
+// ownedBy returns true if the ownership information of the record belongs to
+// the given group. If a key is given, only claims with a valid signature are
+// accepted.
+func ownedBy(r *plan.Record, groupID string, key []byte) bool {
+	if r.Owner == nil || r.Owner.GroupID != groupID {
+		return false
+	}
+	return len(key) == 0 || verifyOwner(r, key) == nil
+}
+
+// verifyOwner checks the signature of the record's ownership information. Of
+// names with an ownership record only, the type is derived from the name of
+// the ownership record, which is prefixed for CNAME records.
+func verifyOwner(r *plan.Record, key []byte) error {
+	recordType := r.Type
+	if recordType == "" && r.OwnerRecord != nil {
+		recordType = "A"
+		if r.OwnerRecord.Name == pkg.OwnerRecordName(r.Name, "CNAME") {
+			recordType = "CNAME"
+		}
+	}
+	return r.Owner.Verify(r.Name, recordType, r.SetIdentifier, key)
+}
+
+// reportInvalidClaims logs the records claimed by the given group without a
+// valid signature, which ownedBy silently ignores. It's called once per zone
+// and sync, so that each record is reported only once.
+func reportInvalidClaims(records []*plan.Record, groupID string, key []byte) {
+	if len(key) == 0 {
+		return
+	}
+	for _, r := range records {
+		if r.Owner == nil || r.Owner.GroupID != groupID {
+			continue
+		}
+		if err := verifyOwner(r, key); err != nil {
+			log.Warnf("Ignoring ownership claim of record %s for group %s: %v", r.Name, groupID, err)
+		}
+	}
+}
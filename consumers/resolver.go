@@ -0,0 +1,227 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/zalando-incubator/mate/pkg"
+	"github.com/zalando-incubator/mate/plan"
+)
+
+const defaultResolverTimeout = 5 * time.Second
+
+// ResolverOptions configures the resolution of hostname targets.
+type ResolverOptions struct {
+	// The address of the DNS server to resolve hostnames with, e.g.
+	// "10.0.0.2:53". Defaults to the resolver of the system.
+	Address string
+
+	// How long to wait for a hostname to be resolved, defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+type hostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type resolvingConsumer struct {
+	Consumer
+	resolver hostResolver
+	timeout  time.Duration
+}
+
+// NewResolvingConsumer provides a consumer that resolves the hostnames of
+// endpoints to their current IPs before passing them on, so that A records
+// are created instead of aliases or CNAME records. Hostnames are resolved
+// again on every sync.
+func NewResolvingConsumer(consumer Consumer, opts *ResolverOptions) (Consumer, error) {
+	resolver := net.DefaultResolver
+	if opts.Address != "" {
+		address := opts.Address
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, "53")
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, address)
+			},
+		}
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultResolverTimeout
+	}
+
+	return &resolvingConsumer{Consumer: consumer, resolver: resolver, timeout: timeout}, nil
+}
+
+// Sync resolves the hostnames of the endpoints and passes them on. Endpoints
+// whose hostname can't be resolved are left out, and if the wrapped consumer
+// supports planning, the changes of their names are left out as well, so
+// that their records are kept. Consumers which don't support planning only
+// get the resolved endpoints.
+func (r *resolvingConsumer) Sync(endpoints []*pkg.Endpoint) error {
+	resolved, unresolved := r.resolve(endpoints)
+	if len(unresolved) == 0 {
+		return r.Consumer.Sync(resolved)
+	}
+
+	planner, ok := r.Consumer.(Planner)
+	if !ok {
+		log.Warnf("Syncing without endpoints %s whose hostnames couldn't be resolved", strings.Join(unresolved, ", "))
+		return r.Consumer.Sync(resolved)
+	}
+
+	p, err := planner.Plan(resolved)
+	if err != nil {
+		return err
+	}
+	if excluded := p.Exclude(unresolved...); excluded > 0 {
+		log.Warnf("Left out %d changes of endpoints %s whose hostnames couldn't be resolved", excluded, strings.Join(unresolved, ", "))
+	}
+	return planner.Apply(p)
+}
+
+// Process resolves the hostname of the endpoint and passes the endpoints of
+// its IPs on at once if the wrapped consumer supports it, so that they end up
+// in a single record.
+func (r *resolvingConsumer) Process(endpoint *pkg.Endpoint) error {
+	resolved, err := r.resolveEndpoint(endpoint)
+	if err != nil {
+		return err
+	}
+
+	if processor, ok := r.Consumer.(endpointsProcessor); ok {
+		return processor.processEndpoints(resolved)
+	}
+	for _, ep := range resolved {
+		if err := r.Consumer.Process(ep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *resolvingConsumer) Consume(endpoints <-chan *pkg.Endpoint, errors chan<- error, done <-chan struct{}, wg *sync.WaitGroup) {
+	wg.Add(1)
+	defer wg.Done()
+
+	log.Infoln("[Resolver] Listening for events...")
+
+	for {
+		select {
+		case e, ok := <-endpoints:
+			if !ok {
+				log.Info("[Resolver] channel closed")
+				return
+			}
+
+			if err := r.Process(e); err != nil {
+				errors <- err
+			}
+		case <-done:
+			log.Info("[Resolver] Exited consuming loop.")
+			return
+		}
+	}
+}
+
+// Plan resolves the hostnames of the endpoints and passes them on to the
+// wrapped consumer, if it supports planning. The changes of endpoints whose
+// hostname can't be resolved are left out.
+func (r *resolvingConsumer) Plan(endpoints []*pkg.Endpoint) (*plan.Plan, error) {
+	planner, ok := r.Consumer.(Planner)
+	if !ok {
+		return nil, errors.New("consumer doesn't support planning changes")
+	}
+
+	resolved, unresolved := r.resolve(endpoints)
+	p, err := planner.Plan(resolved)
+	if err != nil {
+		return nil, err
+	}
+	p.Exclude(unresolved...)
+	return p, nil
+}
+
+// Apply passes the plan on to the wrapped consumer, if it supports planning.
+func (r *resolvingConsumer) Apply(p *plan.Plan) error {
+	planner, ok := r.Consumer.(Planner)
+	if !ok {
+		return errors.New("consumer doesn't support planning changes")
+	}
+	return planner.Apply(p)
+}
+
+// resolve replaces every endpoint with a hostname but no IP with one endpoint
+// per IPv4 address of the hostname. Endpoints whose hostname can't be
+// resolved are reported and left out, their DNS names are returned.
+func (r *resolvingConsumer) resolve(endpoints []*pkg.Endpoint) (resolved []*pkg.Endpoint, unresolved []string) {
+	resolved = make([]*pkg.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		eps, err := r.resolveEndpoint(ep)
+		if err != nil {
+			log.Errorf("Skipping endpoint: %v", err)
+			unresolved = append(unresolved, ep.DNSName)
+			continue
+		}
+		resolved = append(resolved, eps...)
+	}
+	return resolved, unresolved
+}
+
+// resolveEndpoint returns one endpoint per IPv4 address of the hostname of the
+// endpoint, or the endpoint itself if it has an IP or no hostname.
+func (r *resolvingConsumer) resolveEndpoint(ep *pkg.Endpoint) ([]*pkg.Endpoint, error) {
+	if ep.IP != "" || ep.Hostname == "" {
+		return []*pkg.Endpoint{ep}, nil
+	}
+
+	ips, err := r.lookup(ep.Hostname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s of endpoint %s: %v", ep.Hostname, ep.DNSName, err)
+	}
+	log.Debugf("Resolved %s of endpoint %s to %v", ep.Hostname, ep.DNSName, ips)
+
+	resolved := make([]*pkg.Endpoint, 0, len(ips))
+	for _, ip := range ips {
+		e := *ep
+		e.IP, e.Hostname = ip, ""
+		resolved = append(resolved, &e)
+	}
+	return resolved, nil
+}
+
+// lookup returns the sorted IPv4 addresses of the hostname.
+func (r *resolvingConsumer) lookup(hostname string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	addresses, err := r.resolver.LookupHost(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []string
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip != nil && ip.To4() != nil {
+			ips = append(ips, ip.String())
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no IPv4 addresses found")
+	}
+	sort.Strings(ips)
+	return ips, nil
+}
@@ -0,0 +1,126 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/zalando-incubator/mate/pkg"
+	awstest "github.com/zalando-incubator/mate/pkg/aws/test"
+)
+
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addresses, exists := f[host]
+	if !exists {
+		return nil, errors.New("no such host")
+	}
+	return addresses, nil
+}
+
+type recordingConsumer struct {
+	synced    []*pkg.Endpoint
+	processed []*pkg.Endpoint
+}
+
+func (c *recordingConsumer) Sync(endpoints []*pkg.Endpoint) error {
+	c.synced = endpoints
+	return nil
+}
+
+func (c *recordingConsumer) Consume(<-chan *pkg.Endpoint, chan<- error, <-chan struct{}, *sync.WaitGroup) {}
+
+func (c *recordingConsumer) Process(endpoint *pkg.Endpoint) error {
+	c.processed = append(c.processed, endpoint)
+	return nil
+}
+
+func TestResolvingConsumerSync(t *testing.T) {
+	resolver := fakeResolver{"lb.example.org": {"10.0.0.2", "::1", "10.0.0.1"}}
+	inner := &recordingConsumer{}
+	consumer := &resolvingConsumer{Consumer: inner, resolver: resolver, timeout: defaultResolverTimeout}
+
+	err := consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "foo.example.org", Hostname: "lb.example.org", Resource: "service/default/foo"},
+		{DNSName: "bar.example.org", IP: "1.2.3.4"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*pkg.Endpoint{
+		{DNSName: "foo.example.org", IP: "10.0.0.1", Resource: "service/default/foo"},
+		{DNSName: "foo.example.org", IP: "10.0.0.2", Resource: "service/default/foo"},
+		{DNSName: "bar.example.org", IP: "1.2.3.4"},
+	}
+	if !reflect.DeepEqual(inner.synced, expected) {
+		t.Errorf("expected the hostname to be resolved to its IPv4 addresses, got %v", inner.synced)
+	}
+
+	resolver["lb.example.org"] = []string{"10.0.0.3"}
+	if err := consumer.Sync([]*pkg.Endpoint{{DNSName: "foo.example.org", Hostname: "lb.example.org"}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(inner.synced) != 1 || inner.synced[0].IP != "10.0.0.3" {
+		t.Errorf("expected the hostname to be resolved again, got %v", inner.synced)
+	}
+
+	err = consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "foo.example.org", Hostname: "unknown.example.org"},
+		{DNSName: "bar.example.org", IP: "1.2.3.4"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inner.synced) != 1 || inner.synced[0].DNSName != "bar.example.org" {
+		t.Errorf("expected the resolved endpoints to be passed on if a hostname can't be resolved and the consumer can't plan, got %v", inner.synced)
+	}
+}
+
+func TestResolvingConsumerKeepsUnresolvedRecords(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	resolver := fakeResolver{"lb.example.org": {"10.0.0.1"}}
+	consumer := &resolvingConsumer{Consumer: withClient(client, groupID), resolver: resolver, timeout: defaultResolverTimeout}
+
+	err := consumer.Sync([]*pkg.Endpoint{
+		{DNSName: "public-ip.foo.com", Hostname: "unknown.example.org"},
+		{DNSName: "new.foo.com", Hostname: "lb.example.org"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rs := range client.LastDelete["foo.com."] {
+		if aws.StringValue(rs.Name) == "public-ip.foo.com." {
+			t.Errorf("expected the record of the unresolved endpoint to be kept, got deletion of %v", rs)
+		}
+	}
+	if upsert := client.LastUpsert["foo.com."]; len(upsert) != 2 || aws.StringValue(upsert[0].Name) != "new.foo.com." {
+		t.Errorf("expected the record of the resolved endpoint to be created, got %v", upsert)
+	}
+}
+
+func TestResolvingConsumerProcess(t *testing.T) {
+	groupID := "testing-group-id"
+	client := awstest.NewClient(groupID, awstest.GetOriginalState(fmt.Sprintf("\"mate:%s\"", groupID)), awstest.GetHostedZones())
+	resolver := fakeResolver{"lb.example.org": {"10.0.0.2", "10.0.0.1"}}
+	consumer := &resolvingConsumer{Consumer: withClient(client, groupID), resolver: resolver, timeout: defaultResolverTimeout}
+
+	if err := consumer.Process(&pkg.Endpoint{DNSName: "new.foo.com", Hostname: "lb.example.org"}); err != nil {
+		t.Fatal(err)
+	}
+
+	create := client.LastCreate["foo.com."]
+	if len(create) != 2 || len(create[0].ResourceRecords) != 2 {
+		t.Fatalf("expected a single A record with both IPs and its ownership record, got %v", create)
+	}
+	if ip := aws.StringValue(create[0].ResourceRecords[1].Value); ip != "10.0.0.2" {
+		t.Errorf("expected the record to point to 10.0.0.1 and 10.0.0.2, got %v", create[0].ResourceRecords)
+	}
+}
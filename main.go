@@ -7,6 +7,8 @@ import (
 
 	"github.com/zalando-incubator/mate/consumers"
 	"github.com/zalando-incubator/mate/controller"
+	"github.com/zalando-incubator/mate/pkg"
+	"github.com/zalando-incubator/mate/plan"
 	"github.com/zalando-incubator/mate/producers"
 )
 
@@ -25,6 +27,19 @@ func main() {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	switch cfg.command {
+	case "plan":
+		if err := runPlan(cfg); err != nil {
+			log.Fatalf("Error planning changes: %v", err)
+		}
+		return
+	case "apply":
+		if err := runApply(cfg); err != nil {
+			log.Fatalf("Error applying plan: %v", err)
+		}
+		return
+	}
+
 	p, err := newProducer(cfg)
 	if err != nil {
 		log.Fatalf("Error creating producer: %v", err)
@@ -50,23 +65,130 @@ func main() {
 	ctrl.Wait()
 }
 
+// runPlan computes the changes for the currently produced endpoints, prints
+// them and optionally saves them for a later apply.
+func runPlan(cfg *mateConfig) error {
+	planner, err := newPlanner(cfg)
+	if err != nil {
+		return err
+	}
+
+	p, err := newProducer(cfg)
+	if err != nil {
+		return fmt.Errorf("Error creating producer: %v", err)
+	}
+
+	endpoints, err := p.Endpoints()
+	if err != nil {
+		return fmt.Errorf("Error getting endpoints from producer: %v", err)
+	}
+
+	changes, err := planner.Plan(endpoints)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(changes)
+
+	if cfg.planFile == "" {
+		return nil
+	}
+	return changes.Save(cfg.planFile)
+}
+
+// runApply applies a previously saved plan.
+func runApply(cfg *mateConfig) error {
+	planner, err := newPlanner(cfg)
+	if err != nil {
+		return err
+	}
+
+	changes, err := plan.Load(cfg.planFile)
+	if err != nil {
+		return err
+	}
+
+	return planner.Apply(changes)
+}
+
+func newPlanner(cfg *mateConfig) (consumers.Planner, error) {
+	consumer, err := newConsumer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating consumer: %v", err)
+	}
+
+	planner, ok := consumer.(consumers.Planner)
+	if !ok {
+		return nil, fmt.Errorf("Consumer '%s' doesn't support planning changes.", cfg.consumer)
+	}
+	return planner, nil
+}
+
 func newSynchronizedConsumer(cfg *mateConfig) (consumers.Consumer, error) {
-	var consumer consumers.Consumer
-	var err error
+	consumer, err := newConsumer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return consumers.NewSynchronizedConsumer(consumer)
+}
+
+func newConsumer(cfg *mateConfig) (consumers.Consumer, error) {
+	consumer, err := newProviderConsumer(cfg)
+	if err != nil || !cfg.resolveHostnames {
+		return consumer, err
+	}
+	return consumers.NewResolvingConsumer(consumer, &consumers.ResolverOptions{Address: cfg.resolverAddress})
+}
+
+func newProviderConsumer(cfg *mateConfig) (consumers.Consumer, error) {
+	key, err := cfg.ownershipSecret()
+	if err != nil {
+		return nil, fmt.Errorf("Error reading ownership key: %v", err)
+	}
+
 	switch cfg.consumer {
 	case "google":
-		consumer, err = consumers.NewGoogleCloudDNSConsumer(cfg.googleProject, cfg.googleRecordGroupID)
+		return consumers.NewGoogleCloudDNSConsumer(&consumers.GoogleOptions{
+			Project:             cfg.googleProject,
+			RecordGroupID:       cfg.googleRecordGroupID,
+			ClusterName:         cfg.clusterName,
+			OwnershipKey:        key,
+			DryRun:              cfg.dryRun,
+			MaxDeletions:        cfg.deletionLimit(),
+			Policy:              plan.Policy(cfg.policy),
+			DomainFilter:        pkg.DomainFilter{Include: cfg.domainFilter, Exclude: cfg.excludeDomains},
+			Zones:               cfg.googleZones,
+			ZoneLabels:          cfg.googleZoneLabels,
+			ZoneVisibility:      cfg.googleZoneVisibility,
+			ZoneRefreshInterval: cfg.googleZoneRefreshInterval,
+			Endpoint:            cfg.googleEndpoint,
+			CredentialsFile:     cfg.googleCredentials,
+		})
 	case "aws":
-		consumer, err = consumers.NewAWSRoute53Consumer(cfg.awsRecordGroupID)
+		return consumers.NewAWSRoute53Consumer(&consumers.AWSOptions{
+			RecordGroupID:       cfg.awsRecordGroupID,
+			ClusterName:         cfg.clusterName,
+			OwnershipKey:        key,
+			DryRun:              cfg.dryRun,
+			MaxDeletions:        cfg.deletionLimit(),
+			Policy:              plan.Policy(cfg.policy),
+			DomainFilter:        pkg.DomainFilter{Include: cfg.domainFilter, Exclude: cfg.excludeDomains},
+			ZoneIDs:             cfg.awsZoneIDs,
+			ZoneTags:            cfg.awsZoneTags,
+			ZoneType:            cfg.awsZoneType,
+			LoadBalancerRegions: cfg.awsLBRegions,
+			Route53RoleARN:      cfg.awsRoleARN,
+			Route53ExternalID:   cfg.awsExternalID,
+			Route53Endpoint:     cfg.awsR53Endpoint,
+			ELBEndpoint:         cfg.awsELBEndpoint,
+			STSEndpoint:         cfg.awsSTSEndpoint,
+			Region:              cfg.awsRegion,
+			Profile:             cfg.awsProfile,
+		})
 	case "stdout":
-		consumer, err = consumers.NewStdoutConsumer()
-	default:
-		return nil, fmt.Errorf("Unknown consumer '%s'.", cfg.consumer)
-	}
-	if err != nil {
-		return nil, err
+		return consumers.NewStdoutConsumer()
 	}
-	return consumers.NewSynchronizedConsumer(consumer)
+	return nil, fmt.Errorf("Unknown consumer '%s'.", cfg.consumer)
 }
 
 func newProducer(cfg *mateConfig) (producers.Producer, error) {
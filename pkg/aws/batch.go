@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/zalando-incubator/mate/pkg"
+)
+
+const (
+	maxBatchRecords = 1000  // maximum number of ResourceRecord elements per ChangeResourceRecordSets request
+	maxBatchChars   = 32000 // maximum number of characters in all Value elements per ChangeResourceRecordSets request
+
+	errCodeInvalidChangeBatch = "InvalidChangeBatch"
+	errCodeInvalidInput       = "InvalidInput"
+)
+
+type changeBatch struct {
+	changes []*route53.Change
+	records int
+	chars   int
+}
+
+//batchChanges splits the changes into batches within the Route53 limits. All changes to records of the same name,
+//e.g. a record and its ownership TXT record, end up in the same batch. Changes to a single name exceeding the
+//limits on their own are put into a batch of their own
+func batchChanges(changes []*route53.Change) [][]*route53.Change {
+	var batches [][]*route53.Change
+	current := &changeBatch{}
+	for _, changes := range groupChanges(changes) {
+		group := &changeBatch{}
+		for _, change := range changes {
+			records, chars := changeSize(change)
+			group.changes = append(group.changes, change)
+			group.records += records
+			group.chars += chars
+		}
+
+		if len(current.changes) > 0 && (current.records+group.records > maxBatchRecords || current.chars+group.chars > maxBatchChars) {
+			batches = append(batches, current.changes)
+			current = &changeBatch{}
+		}
+		current.changes = append(current.changes, group.changes...)
+		current.records += group.records
+		current.chars += group.chars
+	}
+	if len(current.changes) > 0 {
+		batches = append(batches, current.changes)
+	}
+	return batches
+}
+
+//changeSize returns the number of records and characters a change counts towards the Route53 limits,
+//UPSERTs count twice
+func changeSize(change *route53.Change) (records, chars int) {
+	rs := change.ResourceRecordSet
+	if rs.AliasTarget != nil {
+		records, chars = 1, len(aws.StringValue(rs.AliasTarget.DNSName))
+	}
+	for _, r := range rs.ResourceRecords {
+		records++
+		chars += len(aws.StringValue(r.Value))
+	}
+	if aws.StringValue(change.Action) == route53.ChangeActionUpsert {
+		records, chars = 2*records, 2*chars
+	}
+	return records, chars
+}
+
+//groupChanges groups the changes by record name, keeping the order of the names. The TXT records holding the
+//ownership of CNAME records are grouped with them
+func groupChanges(changes []*route53.Change) [][]*route53.Change {
+	var names []string
+	byName := map[string][]*route53.Change{}
+	for _, change := range changes {
+		name := pkg.OwnedRecordName(aws.StringValue(change.ResourceRecordSet.Name))
+		if _, exists := byName[name]; !exists {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], change)
+	}
+
+	groups := make([][]*route53.Change, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, byName[name])
+	}
+	return groups
+}
+
+//rejectedChanges holds changes to a record Route53 refused to apply along with the reason
+type rejectedChanges struct {
+	changes []*route53.Change
+	err     error
+}
+
+func (r *rejectedChanges) String() string {
+	var names []string
+	for _, group := range groupChanges(r.changes) {
+		names = append(names, aws.StringValue(group[0].ResourceRecordSet.Name))
+	}
+	return fmt.Sprintf("%s: %v", strings.Join(names, ", "), r.err)
+}
+
+//submitBisecting submits a batch of changes. If Route53 rejects the batch as invalid, it is split in halves
+//along the record names and each half is submitted again, until all valid changes are applied and only the
+//changes to the offending records remain. These are returned
+func submitBisecting(batch []*route53.Change, submit func([]*route53.Change) error) []*rejectedChanges {
+	err := submit(batch)
+	if err == nil {
+		return nil
+	}
+
+	groups := groupChanges(batch)
+	if len(groups) == 1 || !isInvalidChange(err) {
+		return []*rejectedChanges{{changes: batch, err: err}}
+	}
+
+	log.Debugf("Change batch of %d records rejected, retrying in halves: %v", len(groups), err)
+	half := len(groups) / 2
+	rejected := submitBisecting(flattenChanges(groups[:half]), submit)
+	return append(rejected, submitBisecting(flattenChanges(groups[half:]), submit)...)
+}
+
+//isInvalidChange returns true if Route53 rejected a batch due to one of its changes, e.g. a CREATE of an
+//existing record or a DELETE of a record that doesn't match
+func isInvalidChange(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == errCodeInvalidChangeBatch || awsErr.Code() == errCodeInvalidInput
+	}
+	return false
+}
+
+func flattenChanges(groups [][]*route53.Change) []*route53.Change {
+	var changes []*route53.Change
+	for _, group := range groups {
+		changes = append(changes, group...)
+	}
+	return changes
+}
@@ -0,0 +1,122 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+func recordChanges(action, name string, values ...string) *route53.Change {
+	rs := &route53.ResourceRecordSet{Name: aws.String(name), Type: aws.String("TXT")}
+	for _, v := range values {
+		rs.ResourceRecords = append(rs.ResourceRecords, &route53.ResourceRecord{Value: aws.String(v)})
+	}
+	return &route53.Change{Action: aws.String(action), ResourceRecordSet: rs}
+}
+
+func TestBatchChangesRecordLimit(t *testing.T) {
+	var changes []*route53.Change
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("foo-%d.example.org.", i)
+		changes = append(changes, recordChanges("UPSERT", name, "1.2.3.4"), recordChanges("UPSERT", name, "owner"))
+	}
+
+	batches := batchChanges(changes)
+	if len(batches) != 2 {
+		t.Fatalf("expected 600 upserts counting as 1200 records to be split into 2 batches, got %d", len(batches))
+	}
+
+	total := 0
+	for _, batch := range batches {
+		records := 0
+		for _, change := range batch {
+			r, _ := changeSize(change)
+			records += r
+		}
+		if records > maxBatchRecords {
+			t.Errorf("batch exceeds the record limit with %d records", records)
+		}
+		if aws.StringValue(batch[0].ResourceRecordSet.Name) != aws.StringValue(batch[1].ResourceRecordSet.Name) {
+			t.Errorf("expected record and ownership record to be in the same batch")
+		}
+		total += len(batch)
+	}
+	if total != len(changes) {
+		t.Errorf("expected %d changes in all batches, got %d", len(changes), total)
+	}
+}
+
+func TestBatchChangesCharLimit(t *testing.T) {
+	value := strings.Repeat("x", 10000)
+	changes := []*route53.Change{
+		recordChanges("CREATE", "foo.example.org.", value),
+		recordChanges("CREATE", "bar.example.org.", value),
+		recordChanges("DELETE", "foo.example.org.", value),
+		recordChanges("UPSERT", "qux.example.org.", value),
+	}
+
+	batches := batchChanges(changes)
+	if len(batches) != 2 || len(batches[0]) != 3 || len(batches[1]) != 1 {
+		t.Fatalf("expected batches of 3 and 1 changes, got %v", batches)
+	}
+	if aws.StringValue(batches[0][1].ResourceRecordSet.Name) != "foo.example.org." {
+		t.Errorf("expected changes to the same name to be batched together, got %v", batches[0])
+	}
+}
+
+func TestSubmitBisecting(t *testing.T) {
+	var changes []*route53.Change
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("foo-%d.example.org.", i)
+		changes = append(changes, recordChanges("CREATE", name, "1.2.3.4"), recordChanges("CREATE", name, "owner"))
+	}
+
+	bad := map[string]bool{"foo-3.example.org.": true, "foo-8.example.org.": true}
+	applied := map[string]bool{}
+	submit := func(batch []*route53.Change) error {
+		for _, change := range batch {
+			if name := aws.StringValue(change.ResourceRecordSet.Name); bad[name] {
+				return awserr.New(errCodeInvalidChangeBatch, "Tried to create resource record set "+name+" but it already exists", nil)
+			}
+		}
+		for _, change := range batch {
+			applied[aws.StringValue(change.ResourceRecordSet.Name)] = true
+		}
+		return nil
+	}
+
+	rejected := submitBisecting(changes, submit)
+
+	if len(rejected) != 2 {
+		t.Fatalf("expected the changes to 2 records to be rejected, got %v", rejected)
+	}
+	for _, r := range rejected {
+		if len(r.changes) != 2 || !bad[aws.StringValue(r.changes[0].ResourceRecordSet.Name)] {
+			t.Errorf("expected only the offending record and its ownership record to be rejected, got %s", r)
+		}
+	}
+	if len(applied) != 8 {
+		t.Errorf("expected all valid changes to be applied, got %v", applied)
+	}
+}
+
+func TestSubmitBisectingOtherErrors(t *testing.T) {
+	changes := []*route53.Change{
+		recordChanges("CREATE", "foo.example.org.", "1.2.3.4"),
+		recordChanges("CREATE", "bar.example.org.", "1.2.3.4"),
+	}
+
+	calls := 0
+	rejected := submitBisecting(changes, func([]*route53.Change) error {
+		calls++
+		return awserr.New("Throttling", "Rate exceeded", nil)
+	})
+
+	if calls != 1 || len(rejected) != 1 || len(rejected[0].changes) != 2 {
+		t.Errorf("expected a batch failing for other reasons not to be bisected, got %d calls and %v", calls, rejected)
+	}
+}
@@ -0,0 +1,86 @@
+package aws
+
+import "strings"
+
+const (
+	cloudFrontZoneID  = "Z2FDTNDATAQYW2"
+	awsHostnameSuffix = ".amazonaws.com"
+)
+
+// canonical hosted zone ids of S3 website endpoints by region
+var s3WebsiteZoneIDs = map[string]string{
+	"us-east-1":      "Z3AQBSTGFYJSTF",
+	"us-east-2":      "Z2O1EMRO9K5GLX",
+	"us-west-1":      "Z2F56UZL2M1ACD",
+	"us-west-2":      "Z3BJ6K6RIION7M",
+	"ca-central-1":   "Z1QDHH18159H29",
+	"eu-central-1":   "Z21DNDUVLTQW6Q",
+	"eu-west-1":      "Z1BKCTXD74EZPE",
+	"eu-west-2":      "Z3GKZC51ZF0DB4",
+	"eu-west-3":      "Z3R1K369G5AVDG",
+	"ap-south-1":     "Z11RGJOFQNVJUP",
+	"ap-northeast-1": "Z2M4EHUR26P7ZW",
+	"ap-northeast-2": "Z3W03O7B5YMIYP",
+	"ap-southeast-1": "Z3O0J2DXBE1FTB",
+	"ap-southeast-2": "Z1WCIGYICN2BYD",
+	"sa-east-1":      "Z7KQH4QJS55SO",
+}
+
+// canonical hosted zone ids of regional API Gateway domains by region
+var apiGatewayZoneIDs = map[string]string{
+	"us-east-1":      "Z1UJRXOUMOOFQ8",
+	"us-east-2":      "ZOJJZC49E0EPZ",
+	"us-west-1":      "Z2MUQ32089INYE",
+	"us-west-2":      "Z2OJLYMUO9EFXC",
+	"ca-central-1":   "Z19DQILCV0OWEC",
+	"eu-central-1":   "Z1U9ULNL0V5AJ3",
+	"eu-west-1":      "ZLY8HYME6SFDD",
+	"eu-west-2":      "ZJ5UAJN8Y3Z2Q",
+	"eu-west-3":      "Z3KY65QIEKYHQQ",
+	"ap-south-1":     "Z3VO1THU9YC4UR",
+	"ap-northeast-1": "Z1YSHQZHG15GKL",
+	"ap-northeast-2": "Z20JF4UZKIW1U8",
+	"ap-southeast-1": "ZL327KTPIQFUL",
+	"ap-southeast-2": "Z2RPCDW04V8134",
+	"sa-east-1":      "ZCMLWB8V5SYIT",
+}
+
+//wellKnownZoneID returns the canonical hosted zone id of CloudFront distributions, S3 website endpoints and
+//regional API Gateway domains, which don't need to be looked up, e.g.
+//  d111111abcdef8.cloudfront.net
+//  bucket.s3-website-eu-west-1.amazonaws.com, bucket.s3-website.eu-central-1.amazonaws.com
+//  d-abcdef1234.execute-api.eu-central-1.amazonaws.com
+//returns an empty string for all other hostnames, load balancers are looked up by their names instead
+func wellKnownZoneID(hostname string) string {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if strings.HasSuffix(hostname, ".cloudfront.net") {
+		return cloudFrontZoneID
+	}
+
+	labels := strings.Split(strings.TrimSuffix(hostname, awsHostnameSuffix), ".")
+	if len(labels) < 2 || !strings.HasSuffix(hostname, awsHostnameSuffix) {
+		return ""
+	}
+	service, region := labels[len(labels)-2], labels[len(labels)-1]
+
+	switch {
+	case service == "s3-website":
+		return s3WebsiteZoneIDs[region]
+	case strings.HasPrefix(region, "s3-website-"):
+		return s3WebsiteZoneIDs[strings.TrimPrefix(region, "s3-website-")]
+	case service == "execute-api":
+		return apiGatewayZoneIDs[region]
+	}
+	return ""
+}
+
+//SupportsTargetHealth returns false for alias targets for which Route53 can't evaluate the target health,
+//i.e. CloudFront distributions
+func SupportsTargetHealth(hostname string) bool {
+	return wellKnownZoneID(hostname) != cloudFrontZoneID
+}
+
+//IsAWSHostname returns true for hostnames of AWS services, which are expected to have a canonical hosted zone id
+func IsAWSHostname(hostname string) bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(hostname, ".")), awsHostnameSuffix)
+}
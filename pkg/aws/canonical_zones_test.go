@@ -0,0 +1,39 @@
+package aws
+
+import "testing"
+
+func TestWellKnownZoneID(t *testing.T) {
+	for _, test := range []struct {
+		hostname string
+		zoneID   string
+	}{
+		{"d111111abcdef8.cloudfront.net", cloudFrontZoneID},
+		{"d111111abcdef8.cloudfront.net.", cloudFrontZoneID},
+		{"bucket.s3-website-eu-west-1.amazonaws.com", "Z1BKCTXD74EZPE"},
+		{"bucket.s3-website.eu-central-1.amazonaws.com", "Z21DNDUVLTQW6Q"},
+		{"d-abcdef1234.execute-api.eu-central-1.amazonaws.com", "Z1U9ULNL0V5AJ3"},
+		{"foo-1234567890abcdef.elb.eu-central-1.amazonaws.com", ""},
+		{"foo-1234567890.eu-central-1.elb.amazonaws.com", ""},
+		{"foo.example.org", ""},
+	} {
+		if zoneID := wellKnownZoneID(test.hostname); zoneID != test.zoneID {
+			t.Errorf("wellKnownZoneID(%q) => %q, want %q", test.hostname, zoneID, test.zoneID)
+		}
+	}
+}
+
+func TestIsAWSHostname(t *testing.T) {
+	for _, test := range []struct {
+		hostname string
+		aws      bool
+	}{
+		{"foo-1234567890abcdef.elb.eu-north-1.amazonaws.com", true},
+		{"FOO-1234567890.EU-CENTRAL-1.ELB.AMAZONAWS.COM.", true},
+		{"d111111abcdef8.cloudfront.net", false},
+		{"foo.example.org", false},
+	} {
+		if aws := IsAWSHostname(test.hostname); aws != test.aws {
+			t.Errorf("IsAWSHostname(%q) => %t, want %t", test.hostname, aws, test.aws)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package aws
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +15,9 @@ import (
 
 const (
 	defaultSessionDuration = 30 * time.Minute
+	defaultCacheTTL        = 10 * time.Minute
+	hostedZonePrefix       = "/hostedzone/"
+	maxTagResources        = 10 // maximum number of resources per ListTagsForResources request
 )
 
 // TODO: move to somewhere
@@ -28,10 +33,40 @@ func (l defaultLog) Infoln(args ...interface{}) {
 
 type Options struct {
 	Log Logger
+
+	// How long the canonical hosted zone ids of load balancers are cached,
+	// defaults to 10 minutes.
+	CacheTTL time.Duration
+
+	// The regions to look up load balancers in whose region can't be
+	// inferred from their DNS name. Defaults to the region of the session.
+	Regions []string
+
+	// The role to assume for Route53 calls, e.g. to manage hosted zones of
+	// another account, and the external id required to assume it. Load
+	// balancers are looked up with the default credentials.
+	Route53RoleARN    string
+	Route53ExternalID string
+
+	// Override the endpoints of the Route53, Elastic Load Balancing and STS
+	// APIs, e.g. to use VPC endpoints or a local stand-in, the default region
+	// and the profile of the shared configuration.
+	Route53Endpoint string
+	ELBEndpoint     string
+	STSEndpoint     string
+	Region          string
+	Profile         string
 }
 
 type Client struct {
 	options Options
+
+	clientsMutex sync.Mutex
+	awsSession   *session.Session
+	route53      *route53.Route53
+
+	cacheMutex sync.Mutex
+	lbCache    map[string]*cachedZoneID // map LB DNS -> canonical hosted zone id
 }
 
 var ErrInvalidAWSResponse = errors.New("invalid AWS response")
@@ -42,7 +77,41 @@ func New(o Options) *Client {
 		o.Log = defaultLog{}
 	}
 
-	return &Client{o}
+	if o.CacheTTL == 0 {
+		o.CacheTTL = defaultCacheTTL
+	}
+
+	return &Client{
+		options: o,
+		lbCache: map[string]*cachedZoneID{},
+	}
+}
+
+//session returns the session shared by all AWS clients, it has to be called with the clientsMutex held
+func (c *Client) session() (*session.Session, error) {
+	if c.awsSession != nil {
+		return c.awsSession, nil
+	}
+
+	config := aws.Config{
+		Logger: aws.LoggerFunc(c.options.Log.Infoln),
+		CredentialsChainVerboseErrors: aws.Bool(true),
+	}
+	if c.options.Region != "" {
+		config.Region = aws.String(c.options.Region)
+	}
+
+	awsSession, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+		Profile:           c.options.Profile,
+		Config:            config,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.awsSession = awsSession
+	return c.awsSession, nil
 }
 
 //ListRecordSets retrieve all records existing in the specified hosted zone
@@ -78,88 +147,223 @@ func (c *Client) ChangeRecordSets(upsert, del, create []*route53.ResourceRecordS
 		return err
 	}
 
+	//deletions come first, so that records can be replaced by records of another type conflicting with them,
+	//e.g. an A by a CNAME record, within the same batch
 	var changes []*route53.Change
+	changes = append(changes, createChangesList("DELETE", del)...)
 	changes = append(changes, createChangesList("CREATE", create)...)
 	changes = append(changes, createChangesList("UPSERT", upsert)...)
-	changes = append(changes, createChangesList("DELETE", del)...)
-	if len(changes) > 0 {
-		params := &route53.ChangeResourceRecordSetsInput{
-			ChangeBatch: &route53.ChangeBatch{
-				Changes: changes,
-			},
-			HostedZoneId: aws.String(zoneID),
-		}
-		_, err = client.ChangeResourceRecordSets(params)
-		return err
+
+	var rejected []*rejectedChanges
+	batches := batchChanges(changes)
+	for i, batch := range batches {
+		log.Debugf("Submitting change batch %d/%d of length %d to hosted zone %s", i+1, len(batches), len(batch), zoneID)
+		rejected = append(rejected, submitBisecting(batch, func(changes []*route53.Change) error {
+			params := &route53.ChangeResourceRecordSetsInput{
+				ChangeBatch: &route53.ChangeBatch{
+					Changes: changes,
+				},
+				HostedZoneId: aws.String(zoneID),
+			}
+			_, err := client.ChangeResourceRecordSets(params)
+			return err
+		})...)
+	}
+
+	if len(rejected) == 0 {
+		return nil
+	}
+
+	reasons := make([]string, 0, len(rejected))
+	for _, r := range rejected {
+		log.Errorf("Route53 rejected changes to hosted zone %s: %s", zoneID, r)
+		reasons = append(reasons, r.String())
 	}
-	return nil
+	return fmt.Errorf("failed to apply changes to hosted zone %s: %s", zoneID, strings.Join(reasons, "; "))
 }
 
-// GetHostedZones returns the map hosted zone domain name -> zone id
-func (c *Client) GetHostedZones() (map[string]string, error) {
+// HostedZone describes a Route53 hosted zone
+type HostedZone struct {
+	ID      string
+	Name    string
+	Private bool
+}
+
+// GetHostedZones returns all hosted zones of the account mapped by their id
+func (c *Client) GetHostedZones() (map[string]*HostedZone, error) {
 	client, err := c.initRoute53Client()
 	if err != nil {
 		return nil, err
 	}
 
-	output, err := client.ListHostedZones(nil)
+	hostedZones := map[string]*HostedZone{}
+	err = client.ListHostedZonesPages(&route53.ListHostedZonesInput{}, func(resp *route53.ListHostedZonesOutput, lastPage bool) bool {
+		log.Debugf("Getting a page of hosted zones of length: %d", len(resp.HostedZones))
+		for _, zone := range resp.HostedZones {
+			hostedZones[aws.StringValue(zone.Id)] = &HostedZone{
+				ID:      aws.StringValue(zone.Id),
+				Name:    aws.StringValue(zone.Name),
+				Private: zone.Config != nil && aws.BoolValue(zone.Config.PrivateZone),
+			}
+		}
+		return !lastPage
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	hostedZoneMap := map[string]string{}
-	for _, zone := range output.HostedZones {
-		hostedZoneMap[aws.StringValue(zone.Name)] = aws.StringValue(zone.Id)
-	}
-
-	return hostedZoneMap, nil
+	return hostedZones, nil
 }
 
-//GetCanonicalZoneIDs returns the map of LB (ALB + ELB classic) mapped to its CanonicalHostedZoneId
-func (c *Client) GetCanonicalZoneIDs(lbDNS []string) (map[string]string, error) {
-	var GetLoadBalancerFunc = []func(*session.Session) ([]*LoadBalancer, error){c.getALBs, c.getELBs}
-
-	lbSession, err := session.NewSessionWithOptions(session.Options{
-		SharedConfigState: session.SharedConfigEnable,
-		Config: aws.Config{
-			Logger: aws.LoggerFunc(c.options.Log.Infoln),
-			CredentialsChainVerboseErrors: aws.Bool(true),
-		},
-	})
+// GetHostedZoneTags returns the tags of the given hosted zones mapped by the zone id
+func (c *Client) GetHostedZoneTags(zoneIDs []string) (map[string]map[string]string, error) {
+	client, err := c.initRoute53Client()
 	if err != nil {
 		return nil, err
 	}
 
-	loadBalancers := make([]*LoadBalancer, 0)
+	tags := map[string]map[string]string{}
+	for start := 0; start < len(zoneIDs); start += maxTagResources {
+		end := start + maxTagResources
+		if end > len(zoneIDs) {
+			end = len(zoneIDs)
+		}
 
-	var addLBMutex sync.Mutex
-	var wg sync.WaitGroup
+		ids := map[string]string{} // map resource id -> zone id
+		params := &route53.ListTagsForResourcesInput{
+			ResourceType: aws.String(route53.TagResourceTypeHostedzone),
+		}
+		for _, zoneID := range zoneIDs[start:end] {
+			ids[CleanZoneID(zoneID)] = zoneID
+			params.ResourceIds = append(params.ResourceIds, aws.String(CleanZoneID(zoneID)))
+		}
 
-	for _, getLBs := range GetLoadBalancerFunc {
-		wg.Add(1)
-		go func(getLBs func(*session.Session) ([]*LoadBalancer, error)) {
-			defer wg.Done()
-			lbs, err := getLBs(lbSession)
-			if err != nil {
-				log.Errorf("Error getting LBs: %v. Skipping...", err)
-				return
+		resp, err := client.ListTagsForResources(params)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resp.ResourceTagSets {
+			zoneTags := map[string]string{}
+			for _, tag := range resource.Tags {
+				zoneTags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
 			}
-			addLBMutex.Lock()
-			loadBalancers = append(loadBalancers, lbs...)
-			addLBMutex.Unlock()
-		}(getLBs)
+			tags[ids[aws.StringValue(resource.ResourceId)]] = zoneTags
+		}
 	}
 
-	wg.Wait()
+	return tags, nil
+}
+
+// CleanZoneID returns the zone id without the "/hostedzone/" prefix returned by some API calls
+func CleanZoneID(zoneID string) string {
+	return strings.TrimPrefix(zoneID, hostedZonePrefix)
+}
 
+//GetCanonicalZoneIDs returns the map of LB (ALB + NLB + ELB classic) mapped to its CanonicalHostedZoneId, the well-known
+//zone ids of CloudFront distributions, S3 website endpoints and API Gateway domains are included as well.
+//The zone ids are cached, only load balancers missing from the cache are looked up by their names. If the lookup
+//fails an error is returned, so that load balancers are never mistaken for unknown hostnames
+func (c *Client) GetCanonicalZoneIDs(lbDNS []string) (map[string]string, error) {
 	loadBalancersMap := map[string]string{} //map LB Dns to its canonical hosted zone id
 
+	var lookup []string
 	for _, dns := range lbDNS {
+		if zoneID := wellKnownZoneID(dns); zoneID != "" {
+			loadBalancersMap[dns] = zoneID
+		} else {
+			lookup = append(lookup, dns)
+		}
+	}
+
+	missing := c.cachedZoneIDs(lookup, loadBalancersMap)
+	if len(missing) == 0 {
+		return loadBalancersMap, nil
+	}
+
+	names := map[string][]string{} //map region -> LB names, the region is empty if unknown
+	for _, dns := range missing {
+		if name := loadBalancerName(dns); name != "" {
+			region := loadBalancerRegion(dns)
+			names[region] = append(names[region], name)
+		}
+	}
+
+	var loadBalancers []*LoadBalancer
+	if len(names) > 0 {
+		var err error
+		loadBalancers, err = c.getLoadBalancers(names)
+		if err != nil {
+			return nil, fmt.Errorf("Error getting LBs: %v", err)
+		}
+	}
+
+	found := map[string]string{}
+	for _, dns := range missing {
 		for _, loadBalancer := range loadBalancers {
 			if dns == loadBalancer.DNSName {
+				found[dns] = loadBalancer.CanonicalZoneID
 				loadBalancersMap[dns] = loadBalancer.CanonicalZoneID
 			}
 		}
 	}
+	c.cacheZoneIDs(missing, found)
+
 	return loadBalancersMap, nil
 }
+
+//getLoadBalancers looks up the ALBs, NLBs and classic ELBs with the given names in their regions, names of an unknown
+//region are looked up in all configured regions, or the default region of the session if none are configured.
+//If one of the lookups fails the load balancers found by the others are returned along with the error
+func (c *Client) getLoadBalancers(names map[string][]string) ([]*LoadBalancer, error) {
+	var GetLoadBalancerFunc = []func(*session.Session, string, []string) ([]*LoadBalancer, error){c.getALBs, c.getELBs}
+
+	c.clientsMutex.Lock()
+	lbSession, err := c.session()
+	c.clientsMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancers := make([]*LoadBalancer, 0)
+	var lookupErr error
+
+	var addLBMutex sync.Mutex
+	var wg sync.WaitGroup
+
+	byRegion := map[string][]string{}
+	for region, regionNames := range names {
+		if region != "" {
+			byRegion[region] = append(byRegion[region], regionNames...)
+			continue
+		}
+		regions := c.options.Regions
+		if len(regions) == 0 {
+			regions = []string{""}
+		}
+		for _, r := range regions {
+			byRegion[r] = append(byRegion[r], regionNames...)
+		}
+	}
+
+	for region, regionNames := range byRegion {
+		for _, getLBs := range GetLoadBalancerFunc {
+			wg.Add(1)
+			go func(getLBs func(*session.Session, string, []string) ([]*LoadBalancer, error), region string, names []string) {
+				defer wg.Done()
+				lbs, err := getLBs(lbSession, region, names)
+				addLBMutex.Lock()
+				defer addLBMutex.Unlock()
+				if err != nil {
+					lookupErr = err
+					return
+				}
+				loadBalancers = append(loadBalancers, lbs...)
+			}(getLBs, region, regionNames)
+		}
+	}
+
+	wg.Wait()
+
+	return loadBalancers, lookupErr
+}
@@ -0,0 +1,28 @@
+package aws
+
+import "testing"
+
+func TestRoute53Endpoint(t *testing.T) {
+	c := New(Options{
+		Route53Endpoint: "http://localhost:8080",
+		ELBEndpoint:     "http://localhost:8081",
+		Route53RoleARN:  "arn:aws:iam::123456789012:role/route53",
+		Region:          "eu-central-1",
+	})
+
+	client, err := c.initRoute53Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Endpoint != "http://localhost:8080" {
+		t.Errorf("expected the Route53 endpoint to be overridden, got %s", client.Endpoint)
+	}
+
+	session, err := c.session()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Config.Endpoint != nil {
+		t.Errorf("expected the endpoint of the other services not to be overridden, got %s", *session.Config.Endpoint)
+	}
+}
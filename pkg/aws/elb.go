@@ -1,36 +1,182 @@
 package aws
 
 import (
+	"strings"
+	"time"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 )
 
+const (
+	maxLoadBalancerNames        = 20 // maximum number of names per DescribeLoadBalancers request
+	errCodeLoadBalancerNotFound = "LoadBalancerNotFound"
+	elbHostnameSuffix           = ".elb.amazonaws.com"
+)
+
 //LoadBalancer struct to aggregate ELB and ALB with extracted DNSName and its canonical hosted zone id
 type LoadBalancer struct {
 	DNSName         string
 	CanonicalZoneID string
 }
 
-func (c *Client) getELBs(session *session.Session) ([]*LoadBalancer, error) {
-	result := make([]*LoadBalancer, 0)
+//cachedZoneID is the canonical hosted zone id of a load balancer, empty if no load balancer was found
+type cachedZoneID struct {
+	zoneID  string
+	expires time.Time
+}
+
+//cachedZoneIDs adds the unexpired zone ids of the load balancers from the cache to the map and returns the ones missing
+func (c *Client) cachedZoneIDs(lbDNS []string, zoneIDs map[string]string) []string {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	var missing []string
+	now := time.Now()
+	for _, dns := range lbDNS {
+		cached, exists := c.lbCache[dns]
+		if !exists || now.After(cached.expires) {
+			missing = append(missing, dns)
+			continue
+		}
+		if cached.zoneID != "" {
+			zoneIDs[dns] = cached.zoneID
+		}
+	}
+	return missing
+}
+
+//cacheZoneIDs stores the zone ids found for the load balancers, the ones that weren't found are remembered as well
+func (c *Client) cacheZoneIDs(lbDNS []string, found map[string]string) {
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
+
+	expires := time.Now().Add(c.options.CacheTTL)
+	for _, dns := range lbDNS {
+		c.lbCache[dns] = &cachedZoneID{expires: expires}
+	}
+	for dns, zoneID := range found {
+		c.lbCache[dns] = &cachedZoneID{zoneID: zoneID, expires: expires}
+	}
+}
+
+//splitLoadBalancerDNS returns the first label of the load balancer DNS name, which holds its name, and its region,
+//which is empty if the DNS name doesn't include it. ALBs and classic ELBs have DNS names like
+//"foo-1234567890.eu-central-1.elb.amazonaws.com", NLBs like "foo-1234567890abcdef.elb.eu-central-1.amazonaws.com".
+//Returns empty strings if it's not a load balancer DNS name
+func splitLoadBalancerDNS(dns string) (string, string) {
+	dns = strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(dns, ".")), "dualstack.")
+
+	if strings.HasSuffix(dns, elbHostnameSuffix) {
+		labels := strings.Split(strings.TrimSuffix(dns, elbHostnameSuffix), ".")
+		if len(labels) < 2 {
+			return labels[0], ""
+		}
+		return labels[0], labels[len(labels)-1]
+	}
+
+	labels := strings.Split(strings.TrimSuffix(dns, awsHostnameSuffix), ".")
+	if len(labels) != 3 || labels[1] != "elb" || !strings.HasSuffix(dns, awsHostnameSuffix) {
+		return "", ""
+	}
+	return labels[0], labels[2]
+}
+
+//loadBalancerName returns the name of the ALB, NLB or classic ELB from its DNS name, e.g. "foo" for
+//"internal-foo-1234567890.eu-central-1.elb.amazonaws.com", or an empty string if it's not a load balancer DNS name
+func loadBalancerName(dns string) string {
+	label, _ := splitLoadBalancerDNS(dns)
+	label = strings.TrimPrefix(label, "internal-")
+	i := strings.LastIndex(label, "-")
+	if i <= 0 {
+		return ""
+	}
+	return label[:i]
+}
+
+//loadBalancerRegion returns the region of the ALB, NLB or classic ELB from its DNS name, e.g. "eu-central-1" for
+//"foo-1234567890.eu-central-1.elb.amazonaws.com", or an empty string if the DNS name doesn't include it
+func loadBalancerRegion(dns string) string {
+	_, region := splitLoadBalancerDNS(dns)
+	return region
+}
+
+//regionConfig returns the config overriding the region of the session, nil for the default region
+func regionConfig(region string) *aws.Config {
+	if region == "" {
+		return nil
+	}
+	return &aws.Config{Region: aws.String(region)}
+}
+
+//endpointConfig returns the config overriding the endpoint of a service, nil for its default endpoint
+func endpointConfig(endpoint string) *aws.Config {
+	if endpoint == "" {
+		return nil
+	}
+	return &aws.Config{Endpoint: aws.String(endpoint)}
+}
+
+//isNotFound returns true if the error reports that one of the requested load balancers doesn't exist
+func isNotFound(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == errCodeLoadBalancerNotFound
+}
 
-	client := elb.New(session)
+//describeByNames calls describe with batches of the names. If a batch fails because one of the load balancers
+//doesn't exist, which is expected as the names are looked up as ALBs and classic ELBs alike, the names of the
+//batch are retried one by one
+func describeByNames(names []string, describe func([]string) error) error {
+	for start := 0; start < len(names); start += maxLoadBalancerNames {
+		end := start + maxLoadBalancerNames
+		if end > len(names) {
+			end = len(names)
+		}
+
+		err := describe(names[start:end])
+		if err == nil {
+			continue
+		}
+		if !isNotFound(err) {
+			return err
+		}
+		if end-start == 1 {
+			continue
+		}
+
+		for _, name := range names[start:end] {
+			if err := describe([]string{name}); err != nil && !isNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-	params := &elb.DescribeLoadBalancersInput{}
+func (c *Client) getELBs(session *session.Session, region string, names []string) ([]*LoadBalancer, error) {
+	result := make([]*LoadBalancer, 0)
+
+	client := elb.New(session, regionConfig(region), endpointConfig(c.options.ELBEndpoint))
 
-	err := client.DescribeLoadBalancersPages(params, func(resp *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
-		loadBalancers := resp.LoadBalancerDescriptions
-		log.Debugf("Getting a page of ELBs of length: %d", len(resp.LoadBalancerDescriptions))
-		for _, loadbalancer := range loadBalancers {
-			result = append(result, &LoadBalancer{
-				DNSName:         aws.StringValue(loadbalancer.DNSName),
-				CanonicalZoneID: aws.StringValue(loadbalancer.CanonicalHostedZoneNameID),
-			})
+	err := describeByNames(names, func(names []string) error {
+		params := &elb.DescribeLoadBalancersInput{
+			LoadBalancerNames: aws.StringSlice(names),
 		}
-		return !lastPage
+		return client.DescribeLoadBalancersPages(params, func(resp *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+			loadBalancers := resp.LoadBalancerDescriptions
+			log.Debugf("Getting a page of ELBs in region %q of length: %d", region, len(resp.LoadBalancerDescriptions))
+			for _, loadbalancer := range loadBalancers {
+				result = append(result, &LoadBalancer{
+					DNSName:         aws.StringValue(loadbalancer.DNSName),
+					CanonicalZoneID: aws.StringValue(loadbalancer.CanonicalHostedZoneNameID),
+				})
+			}
+			return !lastPage
+		})
 	})
 
 	if err != nil {
@@ -40,23 +186,26 @@ func (c *Client) getELBs(session *session.Session) ([]*LoadBalancer, error) {
 	return result, nil
 }
 
-func (c *Client) getALBs(session *session.Session) ([]*LoadBalancer, error) {
+func (c *Client) getALBs(session *session.Session, region string, names []string) ([]*LoadBalancer, error) {
 	result := make([]*LoadBalancer, 0)
 
-	client := elbv2.New(session)
-
-	params := &elbv2.DescribeLoadBalancersInput{}
+	client := elbv2.New(session, regionConfig(region), endpointConfig(c.options.ELBEndpoint))
 
-	err := client.DescribeLoadBalancersPages(params, func(resp *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
-		loadBalancers := resp.LoadBalancers
-		log.Debugf("Getting a page of ALBs of length: %d", len(resp.LoadBalancers))
-		for _, loadbalancer := range loadBalancers {
-			result = append(result, &LoadBalancer{
-				DNSName:         aws.StringValue(loadbalancer.DNSName),
-				CanonicalZoneID: aws.StringValue(loadbalancer.CanonicalHostedZoneId),
-			})
+	err := describeByNames(names, func(names []string) error {
+		params := &elbv2.DescribeLoadBalancersInput{
+			Names: aws.StringSlice(names),
 		}
-		return !lastPage
+		return client.DescribeLoadBalancersPages(params, func(resp *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+			loadBalancers := resp.LoadBalancers
+			log.Debugf("Getting a page of ALBs in region %q of length: %d", region, len(resp.LoadBalancers))
+			for _, loadbalancer := range loadBalancers {
+				result = append(result, &LoadBalancer{
+					DNSName:         aws.StringValue(loadbalancer.DNSName),
+					CanonicalZoneID: aws.StringValue(loadbalancer.CanonicalHostedZoneId),
+				})
+			}
+			return !lastPage
+		})
 	})
 
 	if err != nil {
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+func TestLoadBalancerName(t *testing.T) {
+	for _, test := range []struct {
+		dns  string
+		name string
+	}{
+		{"foo-1234567890.eu-central-1.elb.amazonaws.com", "foo"},
+		{"internal-foo-bar-1234567890.eu-central-1.elb.amazonaws.com.", "foo-bar"},
+		{"dualstack.foo-1234567890.us-east-1.elb.amazonaws.com", "foo"},
+		{"foo-bar-1234567890abcdef.elb.eu-north-1.amazonaws.com", "foo-bar"},
+		{"dualstack.foo-1234567890abcdef.elb.ap-east-1.amazonaws.com.", "foo"},
+		{"bucket.s3-website.eu-central-1.amazonaws.com", ""},
+		{"foo.example.org", ""},
+		{"foo.eu-central-1.elb.amazonaws.com", ""},
+	} {
+		if name := loadBalancerName(test.dns); name != test.name {
+			t.Errorf("loadBalancerName(%q) => %q, want %q", test.dns, name, test.name)
+		}
+	}
+}
+
+func TestDescribeByNames(t *testing.T) {
+	var names []string
+	for i := 0; i < 25; i++ {
+		names = append(names, fmt.Sprintf("lb-%d", i))
+	}
+
+	var calls [][]string
+	found := map[string]bool{}
+	err := describeByNames(names, func(batch []string) error {
+		calls = append(calls, batch)
+		for _, name := range batch {
+			if name == "lb-3" {
+				return awserr.New(errCodeLoadBalancerNotFound, "not found", nil)
+			}
+		}
+		for _, name := range batch {
+			found[name] = true
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one batch of 20 failing, 20 single lookups, one batch of 5
+	if len(calls) != 22 || len(found) != 24 {
+		t.Errorf("expected 22 calls finding 24 load balancers, got %d calls finding %d", len(calls), len(found))
+	}
+
+	err = describeByNames(names, func([]string) error { return errors.New("throttled") })
+	if err == nil {
+		t.Error("expected other errors to be returned")
+	}
+}
+
+func TestZoneIDCache(t *testing.T) {
+	c := New(Options{CacheTTL: time.Minute})
+	c.cacheZoneIDs([]string{"foo.elb.amazonaws.com", "missing.elb.amazonaws.com"}, map[string]string{"foo.elb.amazonaws.com": "Z1"})
+
+	zoneIDs := map[string]string{}
+	missing := c.cachedZoneIDs([]string{"foo.elb.amazonaws.com", "missing.elb.amazonaws.com", "bar.elb.amazonaws.com"}, zoneIDs)
+
+	if len(zoneIDs) != 1 || zoneIDs["foo.elb.amazonaws.com"] != "Z1" {
+		t.Errorf("expected the cached zone id to be returned, got %v", zoneIDs)
+	}
+	if len(missing) != 1 || missing[0] != "bar.elb.amazonaws.com" {
+		t.Errorf("expected only uncached load balancers to be missing, got %v", missing)
+	}
+
+	c.lbCache["foo.elb.amazonaws.com"].expires = time.Now().Add(-time.Second)
+	if missing := c.cachedZoneIDs([]string{"foo.elb.amazonaws.com"}, map[string]string{}); len(missing) != 1 {
+		t.Errorf("expected expired entries to be missing, got %v", missing)
+	}
+}
+
+func TestLoadBalancerRegion(t *testing.T) {
+	for _, test := range []struct {
+		dns    string
+		region string
+	}{
+		{"foo-1234567890.eu-central-1.elb.amazonaws.com", "eu-central-1"},
+		{"internal-foo-1234567890.us-east-1.elb.amazonaws.com.", "us-east-1"},
+		{"dualstack.foo-1234567890.eu-west-1.elb.amazonaws.com", "eu-west-1"},
+		{"foo-1234567890abcdef.elb.eu-north-1.amazonaws.com", "eu-north-1"},
+		{"dualstack.foo-1234567890abcdef.elb.ap-east-1.amazonaws.com.", "ap-east-1"},
+		{"foo-1234567890.elb.amazonaws.com", ""},
+		{"foo.example.org", ""},
+	} {
+		if region := loadBalancerRegion(test.dns); region != test.region {
+			t.Errorf("loadBalancerRegion(%q) => %q, want %q", test.dns, region, test.region)
+		}
+	}
+}
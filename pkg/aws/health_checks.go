@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"errors"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+const (
+	errCodeNoSuchHealthCheck        = "NoSuchHealthCheck"
+	errCodeHealthCheckAlreadyExists = "HealthCheckAlreadyExists"
+)
+
+// ErrHealthCheckAlreadyExists is returned when creating a health check with
+// the reference of a deleted health check, or of one with other settings
+var ErrHealthCheckAlreadyExists = errors.New("health check already exists")
+
+// ListHealthChecks returns all health checks of the account mapped by their id
+func (c *Client) ListHealthChecks() (map[string]*route53.HealthCheck, error) {
+	client, err := c.initRoute53Client()
+	if err != nil {
+		return nil, err
+	}
+
+	healthChecks := map[string]*route53.HealthCheck{}
+	err = client.ListHealthChecksPages(&route53.ListHealthChecksInput{}, func(resp *route53.ListHealthChecksOutput, lastPage bool) bool {
+		log.Debugf("Getting a page of health checks of length: %d", len(resp.HealthChecks))
+		for _, check := range resp.HealthChecks {
+			healthChecks[aws.StringValue(check.Id)] = check
+		}
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return healthChecks, nil
+}
+
+// CreateHealthCheck creates a health check and returns its id. Creating a
+// health check with the reference and config of an existing one returns the
+// id of the existing health check instead. References can't be reused once
+// their health check is deleted, ErrHealthCheckAlreadyExists is returned then.
+func (c *Client) CreateHealthCheck(reference string, config *route53.HealthCheckConfig) (string, error) {
+	client, err := c.initRoute53Client()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.CreateHealthCheck(&route53.CreateHealthCheckInput{
+		CallerReference:   aws.String(reference),
+		HealthCheckConfig: config,
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == errCodeHealthCheckAlreadyExists {
+		return "", ErrHealthCheckAlreadyExists
+	}
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.HealthCheck.Id), nil
+}
+
+// DeleteHealthCheck deletes the health check, it succeeds if the health check
+// doesn't exist anymore
+func (c *Client) DeleteHealthCheck(id string) error {
+	client, err := c.initRoute53Client()
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteHealthCheck(&route53.DeleteHealthCheckInput{HealthCheckId: aws.String(id)})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == errCodeNoSuchHealthCheck {
+		return nil
+	}
+	return err
+}
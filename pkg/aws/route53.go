@@ -2,8 +2,10 @@ package aws
 
 import (
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
 var (
@@ -12,18 +14,37 @@ var (
 )
 
 func (c *Client) initRoute53Client() (*route53.Route53, error) {
-	session, err := session.NewSessionWithOptions(session.Options{
-		SharedConfigState: session.SharedConfigEnable,
-		Config: aws.Config{
-			Logger: aws.LoggerFunc(c.options.Log.Infoln),
-			CredentialsChainVerboseErrors: aws.Bool(true),
-		},
-	})
+	c.clientsMutex.Lock()
+	defer c.clientsMutex.Unlock()
+
+	if c.route53 != nil {
+		return c.route53, nil
+	}
+
+	session, err := c.session()
 	if err != nil {
 		return nil, err
 	}
 
-	return route53.New(session), nil
+	c.route53 = route53.New(session, endpointConfig(c.options.Route53Endpoint), c.route53Config(session))
+	return c.route53, nil
+}
+
+//route53Config returns the config for the Route53 client, which assumes the configured role if any
+//the load balancers are still described with the credentials of the session. The role is assumed through the
+//STS endpoint, not the Route53 one
+func (c *Client) route53Config(session *session.Session) *aws.Config {
+	if c.options.Route53RoleARN == "" {
+		return nil
+	}
+
+	credentials := stscreds.NewCredentialsWithClient(sts.New(session, endpointConfig(c.options.STSEndpoint)), c.options.Route53RoleARN, func(p *stscreds.AssumeRoleProvider) {
+		p.Duration = defaultSessionDuration
+		if c.options.Route53ExternalID != "" {
+			p.ExternalID = aws.String(c.options.Route53ExternalID)
+		}
+	})
+	return &aws.Config{Credentials: credentials}
 }
 
 func createChangesList(action string, rsets []*route53.ResourceRecordSet) []*route53.Change {
@@ -1,27 +1,43 @@
 package test
 
 import (
+	"fmt"
 	"sync"
 
+	awssdk "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/zalando-incubator/mate/pkg/aws"
 )
 
 type Client struct {
-	HostedZones    map[string]string
-	Current        map[string][]*route53.ResourceRecordSet
-	LastUpsert     map[string][]*route53.ResourceRecordSet
-	LastDelete     map[string][]*route53.ResourceRecordSet
-	LastCreate     map[string][]*route53.ResourceRecordSet
-	UpdateMapMutex sync.Mutex
+	HostedZones         map[string]*aws.HostedZone
+	ZoneTags            map[string]map[string]string
+	UnknownLBs          map[string]bool // LB DNS names without a canonical hosted zone id
+	LBLookupErr         error           // returned by GetCanonicalZoneIDs if set
+	ChangeErr           error           // returned by ChangeRecordSets if set, without recording the changes
+	Current             map[string][]*route53.ResourceRecordSet
+	LastUpsert          map[string][]*route53.ResourceRecordSet
+	LastDelete          map[string][]*route53.ResourceRecordSet
+	LastCreate          map[string][]*route53.ResourceRecordSet
+	HealthChecks        map[string]*route53.HealthCheck
+	DeletedHealthChecks []string
+	HealthChecksListed  int // number of calls to ListHealthChecks
+	deletedReferences   map[string]bool
+	UpdateMapMutex      sync.Mutex
 }
 
 func NewClient(groupID string, initState map[string][]*route53.ResourceRecordSet, hostedZones map[string]string) *Client {
+	zones := map[string]*aws.HostedZone{}
+	for name, id := range hostedZones {
+		zones[id] = &aws.HostedZone{ID: id, Name: name}
+	}
 	return &Client{
-		HostedZones: hostedZones,
-		Current:     initState,
-		LastCreate:  map[string][]*route53.ResourceRecordSet{},
-		LastDelete:  map[string][]*route53.ResourceRecordSet{},
-		LastUpsert:  map[string][]*route53.ResourceRecordSet{},
+		HostedZones:  zones,
+		HealthChecks: map[string]*route53.HealthCheck{},
+		Current:      initState,
+		LastCreate:   map[string][]*route53.ResourceRecordSet{},
+		LastDelete:   map[string][]*route53.ResourceRecordSet{},
+		LastUpsert:   map[string][]*route53.ResourceRecordSet{},
 	}
 }
 
@@ -32,6 +48,9 @@ func (c *Client) ListRecordSets(zoneID string) ([]*route53.ResourceRecordSet, er
 func (c *Client) ChangeRecordSets(upsert, del, create []*route53.ResourceRecordSet, zoneID string) error {
 	c.UpdateMapMutex.Lock()
 	defer c.UpdateMapMutex.Unlock()
+	if c.ChangeErr != nil {
+		return c.ChangeErr
+	}
 	if len(create) > 0 {
 		c.LastCreate[zoneID] = create
 	}
@@ -45,14 +64,66 @@ func (c *Client) ChangeRecordSets(upsert, del, create []*route53.ResourceRecordS
 }
 
 func (c *Client) GetCanonicalZoneIDs(lbDNS []string) (map[string]string, error) {
+	if c.LBLookupErr != nil {
+		return nil, c.LBLookupErr
+	}
 	loadBalancersMap := map[string]string{} //map LB Dns to its canonical hosted zone id
 
 	for _, dns := range lbDNS {
-		loadBalancersMap[dns] = "random-zone-id"
+		if !c.UnknownLBs[dns] {
+			loadBalancersMap[dns] = "random-zone-id"
+		}
 	}
 	return loadBalancersMap, nil
 }
 
-func (c *Client) GetHostedZones() (map[string]string, error) {
+func (c *Client) GetHostedZones() (map[string]*aws.HostedZone, error) {
 	return c.HostedZones, nil
 }
+
+func (c *Client) GetHostedZoneTags(zoneIDs []string) (map[string]map[string]string, error) {
+	tags := map[string]map[string]string{}
+	for _, id := range zoneIDs {
+		tags[id] = c.ZoneTags[id]
+	}
+	return tags, nil
+}
+
+func (c *Client) ListHealthChecks() (map[string]*route53.HealthCheck, error) {
+	c.HealthChecksListed++
+	return c.HealthChecks, nil
+}
+
+func (c *Client) CreateHealthCheck(reference string, config *route53.HealthCheckConfig) (string, error) {
+	c.UpdateMapMutex.Lock()
+	defer c.UpdateMapMutex.Unlock()
+	if c.deletedReferences[reference] {
+		return "", aws.ErrHealthCheckAlreadyExists
+	}
+	for id, check := range c.HealthChecks {
+		if awssdk.StringValue(check.CallerReference) == reference {
+			return id, nil
+		}
+	}
+	id := fmt.Sprintf("health-check-%d", len(c.HealthChecks)+len(c.DeletedHealthChecks)+1)
+	c.HealthChecks[id] = &route53.HealthCheck{
+		Id:                awssdk.String(id),
+		CallerReference:   awssdk.String(reference),
+		HealthCheckConfig: config,
+	}
+	return id, nil
+}
+
+func (c *Client) DeleteHealthCheck(id string) error {
+	c.UpdateMapMutex.Lock()
+	defer c.UpdateMapMutex.Unlock()
+	if check, exists := c.HealthChecks[id]; exists {
+		if c.deletedReferences == nil {
+			c.deletedReferences = map[string]bool{}
+		}
+		c.deletedReferences[awssdk.StringValue(check.CallerReference)] = true
+	}
+	delete(c.HealthChecks, id)
+	c.DeletedHealthChecks = append(c.DeletedHealthChecks, id)
+	return nil
+}
@@ -0,0 +1,54 @@
+package pkg
+
+import "strings"
+
+// DomainFilter restricts the DNS names to manage to a set of domains and
+// their subdomains. The zero value matches everything.
+type DomainFilter struct {
+	// The domains to manage. All domains are managed if empty.
+	Include []string
+
+	// The domains never to manage, even if included.
+	Exclude []string
+}
+
+// Match returns true if the given DNS name is managed.
+func (f DomainFilter) Match(name string) bool {
+	if len(f.Include) > 0 && !matchAny(name, f.Include) {
+		return false
+	}
+	return !matchAny(name, f.Exclude)
+}
+
+// MatchZone returns true if the zone with the given name may contain managed
+// DNS names, i.e. if it's managed itself or a parent of an included domain.
+func (f DomainFilter) MatchZone(zone string) bool {
+	if matchAny(zone, f.Exclude) {
+		return false
+	}
+	if len(f.Include) == 0 || matchAny(zone, f.Include) {
+		return true
+	}
+	for _, domain := range f.Include {
+		if isSubdomain(domain, zone) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(name string, domains []string) bool {
+	for _, domain := range domains {
+		if isSubdomain(name, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubdomain returns true if name equals domain or is one of its subdomains.
+func isSubdomain(name, domain string) bool {
+	name = strings.ToLower(SanitizeDNSName(name))
+	domain = strings.ToLower(SanitizeDNSName(domain))
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
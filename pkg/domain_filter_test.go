@@ -0,0 +1,53 @@
+package pkg
+
+import "testing"
+
+func TestDomainFilterMatch(t *testing.T) {
+	filter := DomainFilter{
+		Include: []string{"example.org", "example.com."},
+		Exclude: []string{"internal.example.org"},
+	}
+
+	for _, test := range []struct {
+		name  string
+		match bool
+	}{
+		{"example.org.", true},
+		{"foo.example.org", true},
+		{"FOO.Example.COM.", true},
+		{"internal.example.org.", false},
+		{"foo.internal.example.org.", false},
+		{"badexample.org.", false},
+		{"example.net.", false},
+	} {
+		if match := filter.Match(test.name); match != test.match {
+			t.Errorf("Match(%q) => %t, want %t", test.name, match, test.match)
+		}
+	}
+
+	if !(DomainFilter{}).Match("anything.example.net.") {
+		t.Error("expected an empty filter to match everything")
+	}
+}
+
+func TestDomainFilterMatchZone(t *testing.T) {
+	filter := DomainFilter{
+		Include: []string{"team.example.org"},
+		Exclude: []string{"other.example.org"},
+	}
+
+	for _, test := range []struct {
+		zone  string
+		match bool
+	}{
+		{"example.org.", true},
+		{"team.example.org.", true},
+		{"sub.team.example.org.", true},
+		{"other.example.org.", false},
+		{"example.com.", false},
+	} {
+		if match := filter.MatchZone(test.zone); match != test.match {
+			t.Errorf("MatchZone(%q) => %t, want %t", test.zone, match, test.match)
+		}
+	}
+}
@@ -15,6 +15,15 @@ type Endpoint struct {
 	// record, in case the provider receives only a hostname for
 	// the service.
 	Hostname string
+
+	// The Kubernetes object the endpoint was generated from, in the
+	// form of kind/namespace/name. It is recorded in the ownership
+	// record and is empty if the producer has no such object.
+	Resource string
+
+	// The annotations of the Kubernetes object the endpoint was generated
+	// from. Consumers read provider specific settings from them.
+	Annotations map[string]string
 }
 
 // SanitizeDNSName return the DNS with a trailing dot
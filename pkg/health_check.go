@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Health check protocols.
+const (
+	HTTPHealthCheck  = "HTTP"
+	HTTPSHealthCheck = "HTTPS"
+	TCPHealthCheck   = "TCP"
+)
+
+// Annotations on Services and Ingresses requesting a health check of their
+// targets.
+const (
+	HealthCheckTypeAnnotation     = "zalando.org/health-check-type"
+	HealthCheckPathAnnotation     = "zalando.org/health-check-path"
+	HealthCheckPortAnnotation     = "zalando.org/health-check-port"
+	HealthCheckIntervalAnnotation = "zalando.org/health-check-interval"
+)
+
+const (
+	defaultHealthCheckPath     = "/"
+	defaultHealthCheckInterval = 30
+)
+
+// HealthCheck describes how a DNS provider checks the targets of a record.
+type HealthCheck struct {
+	// One of HTTP, HTTPS or TCP.
+	Type string `json:"type"`
+
+	// The path requested by HTTP and HTTPS checks.
+	Path string `json:"path,omitempty"`
+
+	// The port the targets are checked on.
+	Port int64 `json:"port"`
+
+	// The number of seconds between two checks.
+	Interval int64 `json:"interval"`
+}
+
+// ParseHealthCheck returns the health check requested by the annotations, nil
+// if there is none. The port defaults to 80 for HTTP and 443 for HTTPS, the
+// path to "/" and the interval to 30 seconds.
+func ParseHealthCheck(annotations map[string]string) (*HealthCheck, error) {
+	checkType, exists := annotations[HealthCheckTypeAnnotation]
+	if !exists {
+		return nil, nil
+	}
+
+	h := &HealthCheck{Type: strings.ToUpper(checkType), Interval: defaultHealthCheckInterval}
+	switch h.Type {
+	case HTTPHealthCheck:
+		h.Port = 80
+	case HTTPSHealthCheck:
+		h.Port = 443
+	case TCPHealthCheck:
+		if annotations[HealthCheckPortAnnotation] == "" {
+			return nil, fmt.Errorf("TCP health checks require the %s annotation", HealthCheckPortAnnotation)
+		}
+	default:
+		return nil, fmt.Errorf("unknown health check type %q", checkType)
+	}
+
+	if h.Type != TCPHealthCheck {
+		h.Path = defaultHealthCheckPath
+		if path, exists := annotations[HealthCheckPathAnnotation]; exists {
+			if !strings.HasPrefix(path, "/") {
+				return nil, fmt.Errorf("invalid health check path %q, must start with /", path)
+			}
+			h.Path = path
+		}
+	}
+
+	if port, exists := annotations[HealthCheckPortAnnotation]; exists {
+		value, err := strconv.ParseInt(port, 10, 64)
+		if err != nil || value < 1 || value > 65535 {
+			return nil, fmt.Errorf("invalid health check port %q", port)
+		}
+		h.Port = value
+	}
+
+	if interval, exists := annotations[HealthCheckIntervalAnnotation]; exists {
+		value, err := strconv.ParseInt(strings.TrimSuffix(interval, "s"), 10, 64)
+		if err != nil || value < 1 {
+			return nil, fmt.Errorf("invalid health check interval %q", interval)
+		}
+		h.Interval = value
+	}
+
+	return h, nil
+}
+
+// Equal returns true if both health checks are nil or have the same settings.
+func (h *HealthCheck) Equal(other *HealthCheck) bool {
+	if h == nil || other == nil {
+		return h == other
+	}
+	return *h == *other
+}
+
+// String returns the settings of the health check, e.g. "HTTP :80/healthz
+// every 30s".
+func (h *HealthCheck) String() string {
+	return fmt.Sprintf("%s :%d%s every %ds", h.Type, h.Port, h.Path, h.Interval)
+}
@@ -0,0 +1,30 @@
+package pkg
+
+import "testing"
+
+func TestParseHealthCheck(t *testing.T) {
+	for _, test := range []struct {
+		annotations map[string]string
+		expected    *HealthCheck
+		valid       bool
+	}{
+		{map[string]string{}, nil, true},
+		{map[string]string{HealthCheckTypeAnnotation: "http"}, &HealthCheck{Type: HTTPHealthCheck, Path: "/", Port: 80, Interval: 30}, true},
+		{map[string]string{HealthCheckTypeAnnotation: "HTTPS", HealthCheckPathAnnotation: "/healthz", HealthCheckIntervalAnnotation: "10s"}, &HealthCheck{Type: HTTPSHealthCheck, Path: "/healthz", Port: 443, Interval: 10}, true},
+		{map[string]string{HealthCheckTypeAnnotation: "tcp", HealthCheckPortAnnotation: "5432", HealthCheckPathAnnotation: "/ignored"}, &HealthCheck{Type: TCPHealthCheck, Port: 5432, Interval: 30}, true},
+		{map[string]string{HealthCheckTypeAnnotation: "tcp"}, nil, false},
+		{map[string]string{HealthCheckTypeAnnotation: "http", HealthCheckPathAnnotation: "healthz"}, nil, false},
+		{map[string]string{HealthCheckTypeAnnotation: "http", HealthCheckPortAnnotation: "70000"}, nil, false},
+		{map[string]string{HealthCheckTypeAnnotation: "http", HealthCheckIntervalAnnotation: "often"}, nil, false},
+		{map[string]string{HealthCheckTypeAnnotation: "icmp"}, nil, false},
+	} {
+		check, err := ParseHealthCheck(test.annotations)
+		if (err == nil) != test.valid {
+			t.Errorf("ParseHealthCheck(%v) => error %v, want valid: %t", test.annotations, err, test.valid)
+			continue
+		}
+		if !check.Equal(test.expected) {
+			t.Errorf("ParseHealthCheck(%v) => %+v, want %+v", test.annotations, check, test.expected)
+		}
+	}
+}
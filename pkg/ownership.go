@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	heritageKey = "heritage"
+	heritage    = "mate"
+
+	groupIDKey  = "mate/record-group-id"
+	resourceKey = "mate/resource"
+	clusterKey  = "mate/cluster"
+	updatedKey  = "mate/updated"
+	membersKey  = "mate/members"
+
+	signatureKey = "mate/signature"
+
+	// records created by earlier versions of Mate on AWS only carry
+	// the group ID in the form of "mate:<group>"
+	legacyPrefix = "mate:"
+
+	// CNAME records can't share their name with the TXT record holding
+	// their ownership, which is stored under the prefixed name instead
+	cnameOwnerPrefix = "_mate-cname."
+)
+
+// Owner describes the ownership information Mate stores in the TXT record
+// next to every record it manages.
+type Owner struct {
+	// The record group ID of the Mate instance owning the record.
+	GroupID string
+
+	// The Kubernetes object the record was created for, in the form of
+	// kind/namespace/name. Empty if unknown.
+	Resource string
+
+	// The name of the cluster the owning Mate instance runs in. Empty if unknown.
+	Cluster string
+
+	// The time of the last change made to the record by Mate. Zero if unknown.
+	Updated time.Time
+
+	// The keys of the members of a record set shared by several Mate
+	// instances which belong to the owner. Empty if the owner holds the
+	// whole record.
+	Members []string
+
+	// The hex encoded HMAC-SHA256 of the record name, type and set
+	// identifier and the other ownership information. Empty if the record
+	// is not signed.
+	Signature string
+}
+
+var (
+	// ErrOwnerNotSigned is returned when verifying an unsigned ownership record.
+	ErrOwnerNotSigned = errors.New("ownership record is not signed")
+
+	// ErrOwnerInvalidSignature is returned when the signature of an
+	// ownership record doesn't match its content.
+	ErrOwnerInvalidSignature = errors.New("ownership record has an invalid signature")
+)
+
+// OwnerRecordName returns the name of the TXT record holding the ownership of
+// the record with the given name and type.
+func OwnerRecordName(name, recordType string) string {
+	if recordType == "CNAME" {
+		return cnameOwnerPrefix + name
+	}
+	return name
+}
+
+// OwnedRecordName returns the name of the record whose ownership is held by
+// the TXT record with the given name.
+func OwnedRecordName(ownerName string) string {
+	return strings.TrimPrefix(ownerName, cnameOwnerPrefix)
+}
+
+// ResourceName returns the identifier of a Kubernetes object as used in
+// ownership records.
+func ResourceName(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", strings.ToLower(kind), namespace, name)
+}
+
+// Labels returns the ownership information as a list of key=value pairs.
+// Empty values are omitted.
+func (o *Owner) Labels() []string {
+	labels := o.unsignedLabels()
+	if o.Signature != "" {
+		labels = append(labels, signatureKey+"="+o.Signature)
+	}
+	return labels
+}
+
+// Sign signs the ownership information of the record with the given name,
+// type and set identifier, which is empty for records without a routing
+// policy.
+func (o *Owner) Sign(name, recordType, setIdentifier string, key []byte) {
+	o.Signature = o.signature(name, recordType, setIdentifier, key)
+}
+
+// Verify checks that the ownership information was signed for the record
+// with the given name, type and set identifier using the given key.
+func (o *Owner) Verify(name, recordType, setIdentifier string, key []byte) error {
+	if o.Signature == "" {
+		return ErrOwnerNotSigned
+	}
+	if !hmac.Equal([]byte(o.Signature), []byte(o.signature(name, recordType, setIdentifier, key))) {
+		return ErrOwnerInvalidSignature
+	}
+	return nil
+}
+
+// signature returns the signature covering the record name, type and set
+// identifier, so that a signed ownership record cannot be copied over to
+// another name or to another member of a policy based record set.
+func (o *Owner) signature(name, recordType, setIdentifier string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(SanitizeDNSName(name) + "\n" + recordType + "\n" + setIdentifier))
+	for _, label := range o.unsignedLabels() {
+		mac.Write([]byte("," + label))
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (o *Owner) unsignedLabels() []string {
+	labels := []string{
+		heritageKey + "=" + heritage,
+		groupIDKey + "=" + o.GroupID,
+	}
+	if o.Resource != "" {
+		labels = append(labels, resourceKey+"="+o.Resource)
+	}
+	if o.Cluster != "" {
+		labels = append(labels, clusterKey+"="+o.Cluster)
+	}
+	if !o.Updated.IsZero() {
+		labels = append(labels, updatedKey+"="+o.Updated.UTC().Format(time.RFC3339))
+	}
+	if len(o.Members) > 0 {
+		labels = append(labels, membersKey+"="+strings.Join(o.Members, "+"))
+	}
+	return labels
+}
+
+// String returns the ownership information in a human readable form.
+func (o *Owner) String() string {
+	return strings.Join(o.Labels(), ",")
+}
+
+// ParseOwner parses the values of an ownership TXT record. Each value may
+// hold one or more quoted, comma or whitespace separated key=value pairs.
+// Unknown keys are ignored. It returns nil if the values don't describe a
+// record created by Mate.
+func ParseOwner(values ...string) *Owner {
+	var owner Owner
+	var isMate bool
+
+	for _, value := range values {
+		fields := strings.FieldsFunc(value, func(r rune) bool {
+			return r == '"' || r == ',' || unicode.IsSpace(r)
+		})
+
+		for _, field := range fields {
+			if strings.HasPrefix(field, legacyPrefix) {
+				isMate = true
+				owner.GroupID = strings.TrimPrefix(field, legacyPrefix)
+				continue
+			}
+
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+
+			switch kv[0] {
+			case heritageKey:
+				isMate = isMate || kv[1] == heritage
+			case groupIDKey:
+				owner.GroupID = kv[1]
+			case resourceKey:
+				owner.Resource = kv[1]
+			case clusterKey:
+				owner.Cluster = kv[1]
+			case updatedKey:
+				if t, err := time.Parse(time.RFC3339, kv[1]); err == nil {
+					owner.Updated = t
+				}
+			case membersKey:
+				owner.Members = strings.Split(kv[1], "+")
+			case signatureKey:
+				owner.Signature = kv[1]
+			}
+		}
+	}
+
+	if !isMate {
+		return nil
+	}
+
+	return &owner
+}
@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOwnerLabelsRoundTrip(t *testing.T) {
+	owner := &Owner{
+		GroupID:  "foo",
+		Resource: ResourceName("Service", "default", "nginx"),
+		Cluster:  "bar",
+		Updated:  time.Date(2016, 12, 1, 10, 0, 0, 0, time.UTC),
+		Members:  []string{"0a1b2c3d", "4e5f6a7b"},
+	}
+
+	labels := owner.Labels()
+	if len(labels) != 6 || labels[2] != "mate/resource=service/default/nginx" || labels[5] != "mate/members=0a1b2c3d+4e5f6a7b" {
+		t.Errorf("Labels() => %v", labels)
+	}
+
+	parsed := ParseOwner(labels...)
+	if parsed == nil || !parsed.Updated.Equal(owner.Updated) {
+		t.Fatalf("ParseOwner(%v) => %v, want %v", labels, parsed, owner)
+	}
+	parsed.Updated = owner.Updated
+	if !reflect.DeepEqual(parsed, owner) {
+		t.Errorf("ParseOwner(%v) => %v, want %v", labels, parsed, owner)
+	}
+}
+
+func TestParseOwner(t *testing.T) {
+	for _, test := range []struct {
+		values  []string
+		groupID string
+		isOwned bool
+	}{
+		{[]string{`"mate:foo"`}, "foo", true},
+		{[]string{`"heritage=mate"`, `"mate/record-group-id=foo"`}, "foo", true},
+		{[]string{`"heritage=mate" "mate/record-group-id=foo" "mate/unknown=qux"`}, "foo", true},
+		{[]string{"heritage=mate,mate/record-group-id=foo,some-flag"}, "foo", true},
+		{[]string{"heritage=external-dns", "mate/record-group-id=foo"}, "", false},
+		{[]string{`"v=spf1 -all"`}, "", false},
+		{nil, "", false},
+	} {
+		owner := ParseOwner(test.values...)
+		if (owner != nil) != test.isOwned {
+			t.Errorf("ParseOwner(%q) => %v, want owned: %t", test.values, owner, test.isOwned)
+			continue
+		}
+		if owner != nil && owner.GroupID != test.groupID {
+			t.Errorf("ParseOwner(%q) => group %q, want %q", test.values, owner.GroupID, test.groupID)
+		}
+	}
+}
+
+func TestOwnerSignature(t *testing.T) {
+	key := []byte("secret")
+	owner := &Owner{GroupID: "foo", Resource: "service/default/nginx"}
+
+	if err := owner.Verify("foo.example.org.", "A", "cluster-a", key); err != ErrOwnerNotSigned {
+		t.Errorf("Verify() of unsigned owner => %v, want %v", err, ErrOwnerNotSigned)
+	}
+
+	owner.Sign("foo.example.org", "A", "cluster-a", key)
+
+	parsed := ParseOwner(owner.Labels()...)
+	if err := parsed.Verify("foo.example.org.", "A", "cluster-a", key); err != nil {
+		t.Errorf("Verify() of signed owner => %v", err)
+	}
+	if err := parsed.Verify("bar.example.org.", "A", "cluster-a", key); err != ErrOwnerInvalidSignature {
+		t.Errorf("Verify() for another name => %v, want %v", err, ErrOwnerInvalidSignature)
+	}
+	if err := parsed.Verify("foo.example.org.", "CNAME", "cluster-a", key); err != ErrOwnerInvalidSignature {
+		t.Errorf("Verify() for another type => %v, want %v", err, ErrOwnerInvalidSignature)
+	}
+	if err := parsed.Verify("foo.example.org.", "A", "cluster-b", key); err != ErrOwnerInvalidSignature {
+		t.Errorf("Verify() for another set identifier => %v, want %v", err, ErrOwnerInvalidSignature)
+	}
+	if err := parsed.Verify("foo.example.org.", "A", "cluster-a", []byte("other")); err != ErrOwnerInvalidSignature {
+		t.Errorf("Verify() with another key => %v, want %v", err, ErrOwnerInvalidSignature)
+	}
+
+	parsed.GroupID = "bar"
+	if err := parsed.Verify("foo.example.org.", "A", "cluster-a", key); err != ErrOwnerInvalidSignature {
+		t.Errorf("Verify() of modified owner => %v, want %v", err, ErrOwnerInvalidSignature)
+	}
+}
+
+func TestOwnerRecordName(t *testing.T) {
+	for _, test := range []struct {
+		name, recordType, ownerName string
+	}{
+		{"foo.example.org.", "A", "foo.example.org."},
+		{"foo.example.org.", "CNAME", "_mate-cname.foo.example.org."},
+	} {
+		ownerName := OwnerRecordName(test.name, test.recordType)
+		if ownerName != test.ownerName {
+			t.Errorf("OwnerRecordName(%q, %q) => %q, want %q", test.name, test.recordType, ownerName, test.ownerName)
+		}
+		if name := OwnedRecordName(ownerName); name != test.name {
+			t.Errorf("OwnedRecordName(%q) => %q, want %q", ownerName, name, test.name)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Routing policy types.
+const (
+	WeightedRouting    = "weighted"
+	LatencyRouting     = "latency"
+	GeolocationRouting = "geolocation"
+	FailoverRouting    = "failover"
+)
+
+// Failover roles of a record with a failover routing policy.
+const (
+	FailoverPrimary   = "primary"
+	FailoverSecondary = "secondary"
+)
+
+// Annotations on Services and Ingresses selecting the routing policy of their
+// records.
+const (
+	RoutingPolicyAnnotation      = "zalando.org/routing-policy"
+	RoutingWeightAnnotation      = "zalando.org/routing-weight"
+	RoutingRegionAnnotation      = "zalando.org/routing-region"
+	RoutingContinentAnnotation   = "zalando.org/routing-continent"
+	RoutingCountryAnnotation     = "zalando.org/routing-country"
+	RoutingSubdivisionAnnotation = "zalando.org/routing-subdivision"
+	RoutingFailoverAnnotation    = "zalando.org/routing-failover"
+)
+
+// RoutingPolicy describes how a DNS provider chooses between several records
+// of the same name, e.g. one per cluster. Only the fields of the policy's
+// type are set.
+type RoutingPolicy struct {
+	// One of weighted, latency, geolocation or failover.
+	Type string `json:"type"`
+
+	// The relative weight of a weighted record.
+	Weight int64 `json:"weight,omitempty"`
+
+	// The region of a latency based record.
+	Region string `json:"region,omitempty"`
+
+	// The location of a geolocation record. Either the continent or the
+	// country is set, the subdivision requires the country.
+	Continent   string `json:"continent,omitempty"`
+	Country     string `json:"country,omitempty"`
+	Subdivision string `json:"subdivision,omitempty"`
+
+	// The role of a failover record, primary or secondary.
+	Failover string `json:"failover,omitempty"`
+}
+
+// ParseRoutingPolicy returns the routing policy selected by the annotations,
+// nil if there is none.
+func ParseRoutingPolicy(annotations map[string]string) (*RoutingPolicy, error) {
+	policyType, exists := annotations[RoutingPolicyAnnotation]
+	if !exists {
+		return nil, nil
+	}
+
+	p := &RoutingPolicy{Type: strings.ToLower(policyType)}
+	switch p.Type {
+	case WeightedRouting:
+		weight, err := strconv.ParseInt(annotations[RoutingWeightAnnotation], 10, 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid routing weight %q, must be a non-negative number", annotations[RoutingWeightAnnotation])
+		}
+		p.Weight = weight
+	case LatencyRouting:
+		if p.Region = annotations[RoutingRegionAnnotation]; p.Region == "" {
+			return nil, fmt.Errorf("latency routing requires the %s annotation", RoutingRegionAnnotation)
+		}
+	case GeolocationRouting:
+		p.Continent = strings.ToUpper(annotations[RoutingContinentAnnotation])
+		p.Country = strings.ToUpper(annotations[RoutingCountryAnnotation])
+		p.Subdivision = strings.ToUpper(annotations[RoutingSubdivisionAnnotation])
+		if p.Continent == "" && p.Country == "" {
+			return nil, fmt.Errorf("geolocation routing requires the %s or %s annotation", RoutingContinentAnnotation, RoutingCountryAnnotation)
+		}
+		if p.Continent != "" && p.Country != "" {
+			return nil, fmt.Errorf("geolocation routing accepts either a continent or a country, not both")
+		}
+		if p.Subdivision != "" && p.Country == "" {
+			return nil, fmt.Errorf("geolocation routing requires a country along with the subdivision")
+		}
+	case FailoverRouting:
+		p.Failover = strings.ToLower(annotations[RoutingFailoverAnnotation])
+		if p.Failover != FailoverPrimary && p.Failover != FailoverSecondary {
+			return nil, fmt.Errorf("invalid failover role %q, must be %s or %s", annotations[RoutingFailoverAnnotation], FailoverPrimary, FailoverSecondary)
+		}
+	default:
+		return nil, fmt.Errorf("unknown routing policy %q", policyType)
+	}
+	return p, nil
+}
+
+// Equal returns true if both policies are nil or have the same settings.
+func (p *RoutingPolicy) Equal(other *RoutingPolicy) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return *p == *other
+}
+
+// String returns the type of the policy along with its settings.
+func (p *RoutingPolicy) String() string {
+	switch p.Type {
+	case WeightedRouting:
+		return fmt.Sprintf("%s %d", p.Type, p.Weight)
+	case LatencyRouting:
+		return p.Type + " " + p.Region
+	case GeolocationRouting:
+		location := p.Continent
+		if p.Country != "" {
+			location = p.Country
+			if p.Subdivision != "" {
+				location += "-" + p.Subdivision
+			}
+		}
+		return p.Type + " " + location
+	case FailoverRouting:
+		return p.Type + " " + p.Failover
+	}
+	return p.Type
+}
@@ -0,0 +1,34 @@
+package pkg
+
+import "testing"
+
+func TestParseRoutingPolicy(t *testing.T) {
+	for _, test := range []struct {
+		annotations map[string]string
+		expected    *RoutingPolicy
+		valid       bool
+	}{
+		{map[string]string{}, nil, true},
+		{map[string]string{RoutingPolicyAnnotation: "weighted", RoutingWeightAnnotation: "10"}, &RoutingPolicy{Type: WeightedRouting, Weight: 10}, true},
+		{map[string]string{RoutingPolicyAnnotation: "weighted"}, nil, false},
+		{map[string]string{RoutingPolicyAnnotation: "weighted", RoutingWeightAnnotation: "-1"}, nil, false},
+		{map[string]string{RoutingPolicyAnnotation: "Latency", RoutingRegionAnnotation: "eu-central-1"}, &RoutingPolicy{Type: LatencyRouting, Region: "eu-central-1"}, true},
+		{map[string]string{RoutingPolicyAnnotation: "latency"}, nil, false},
+		{map[string]string{RoutingPolicyAnnotation: "geolocation", RoutingContinentAnnotation: "eu"}, &RoutingPolicy{Type: GeolocationRouting, Continent: "EU"}, true},
+		{map[string]string{RoutingPolicyAnnotation: "geolocation", RoutingCountryAnnotation: "US", RoutingSubdivisionAnnotation: "CA"}, &RoutingPolicy{Type: GeolocationRouting, Country: "US", Subdivision: "CA"}, true},
+		{map[string]string{RoutingPolicyAnnotation: "geolocation", RoutingSubdivisionAnnotation: "CA"}, nil, false},
+		{map[string]string{RoutingPolicyAnnotation: "geolocation", RoutingContinentAnnotation: "EU", RoutingCountryAnnotation: "DE"}, nil, false},
+		{map[string]string{RoutingPolicyAnnotation: "failover", RoutingFailoverAnnotation: "Primary"}, &RoutingPolicy{Type: FailoverRouting, Failover: FailoverPrimary}, true},
+		{map[string]string{RoutingPolicyAnnotation: "failover", RoutingFailoverAnnotation: "backup"}, nil, false},
+		{map[string]string{RoutingPolicyAnnotation: "random"}, nil, false},
+	} {
+		policy, err := ParseRoutingPolicy(test.annotations)
+		if (err == nil) != test.valid {
+			t.Errorf("ParseRoutingPolicy(%v) => error %v, want valid: %t", test.annotations, err, test.valid)
+			continue
+		}
+		if !policy.Equal(test.expected) {
+			t.Errorf("ParseRoutingPolicy(%v) => %+v, want %+v", test.annotations, policy, test.expected)
+		}
+	}
+}
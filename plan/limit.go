@@ -0,0 +1,68 @@
+package plan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DeletionLimit guards against deleting a large share of the owned records at
+// once, e.g. when the source of endpoints briefly returns nothing. A nil limit
+// doesn't restrict deletions.
+type DeletionLimit struct {
+	// The maximum number of records to delete, used if Percentage is zero.
+	Count int
+
+	// The maximum share of the owned records to delete, in percent.
+	Percentage float64
+}
+
+// ParseDeletionLimit parses either an absolute number of records, e.g. "10",
+// or a percentage of the owned records, e.g. "25%".
+func ParseDeletionLimit(s string) (*DeletionLimit, error) {
+	if strings.HasSuffix(s, "%") {
+		percentage, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil || percentage < 0 || percentage > 100 {
+			return nil, fmt.Errorf("invalid deletion limit %q: percentage must be between 0 and 100", s)
+		}
+		return &DeletionLimit{Percentage: percentage}, nil
+	}
+
+	count, err := strconv.Atoi(s)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid deletion limit %q: must be a non-negative number or a percentage", s)
+	}
+	return &DeletionLimit{Count: count}, nil
+}
+
+// String returns the limit in the format accepted by ParseDeletionLimit.
+func (l *DeletionLimit) String() string {
+	if l.Percentage > 0 {
+		return strconv.FormatFloat(l.Percentage, 'f', -1, 64) + "%"
+	}
+	return strconv.Itoa(l.Count)
+}
+
+// Check returns an error if the plan deletes more records than allowed, taking
+// all of its zones into account.
+func (l *DeletionLimit) Check(p *Plan) error {
+	if l == nil {
+		return nil
+	}
+
+	deletions, owned := 0, 0
+	for _, z := range p.Zones {
+		deletions += len(z.Changes.Delete)
+		owned += z.Owned
+	}
+
+	max := float64(l.Count)
+	if l.Percentage > 0 {
+		max = float64(owned) * l.Percentage / 100
+	}
+
+	if float64(deletions) > max {
+		return fmt.Errorf("refusing to delete %d of %d owned records, exceeding the deletion limit of %s", deletions, owned, l)
+	}
+	return nil
+}
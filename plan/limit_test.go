@@ -0,0 +1,54 @@
+package plan
+
+import "testing"
+
+func TestParseDeletionLimit(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		limit *DeletionLimit
+	}{
+		{"10", &DeletionLimit{Count: 10}},
+		{"0", &DeletionLimit{}},
+		{"25%", &DeletionLimit{Percentage: 25}},
+		{"12.5%", &DeletionLimit{Percentage: 12.5}},
+		{"-1", nil},
+		{"150%", nil},
+		{"ten", nil},
+	} {
+		limit, err := ParseDeletionLimit(test.value)
+		if test.limit == nil {
+			if err == nil {
+				t.Errorf("ParseDeletionLimit(%q) => %v, want error", test.value, limit)
+			}
+			continue
+		}
+		if err != nil || *limit != *test.limit {
+			t.Errorf("ParseDeletionLimit(%q) => %v, %v, want %v", test.value, limit, err, test.limit)
+		}
+		if limit != nil && limit.String() != test.value {
+			t.Errorf("String() => %q, want %q", limit.String(), test.value)
+		}
+	}
+}
+
+func TestDeletionLimitCheck(t *testing.T) {
+	p := &Plan{Zones: []*Zone{
+		{Owned: 6, Changes: &Changes{Delete: []*Record{ownedRecord("a", "A"), ownedRecord("b", "A")}}},
+		{Owned: 4, Changes: &Changes{Delete: []*Record{ownedRecord("c", "A")}}},
+	}}
+
+	for _, test := range []struct {
+		limit *DeletionLimit
+		ok    bool
+	}{
+		{nil, true},
+		{&DeletionLimit{Count: 3}, true},
+		{&DeletionLimit{Count: 2}, false},
+		{&DeletionLimit{Percentage: 30}, true},
+		{&DeletionLimit{Percentage: 20}, false},
+	} {
+		if err := test.limit.Check(p); (err == nil) != test.ok {
+			t.Errorf("%v.Check() => %v, want ok: %t", test.limit, err, test.ok)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package plan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/zalando-incubator/mate/pkg"
+)
+
+// Record is the provider independent representation of a DNS record and its
+// ownership information.
+type Record struct {
+	// The sanitized DNS name of the record.
+	Name string `json:"name"`
+
+	// The type of the record, e.g. A or CNAME. Empty if only an ownership
+	// record exists for the name.
+	Type string `json:"type,omitempty"`
+
+	// The values of the record, e.g. IPs or a load balancer hostname.
+	Targets []string `json:"targets,omitempty"`
+
+	// The TTL of the record. Zero for alias records.
+	TTL int64 `json:"ttl,omitempty"`
+
+	// Attributes hold provider specific settings of the record, e.g. the
+	// hosted zone of an alias target. They are passed on to the provider
+	// but not compared when calculating changes, as they're derived from
+	// the targets.
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Identifies the record among others of the same name with a routing
+	// policy. Empty for simple records.
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+
+	// The routing policy of the record, nil for simple records.
+	Routing *pkg.RoutingPolicy `json:"routing,omitempty"`
+
+	// The members of a single record choosing between several sets of
+	// targets by their routing policy, e.g. the items of a Cloud DNS
+	// routing policy. Members only have targets and a routing policy, the
+	// record itself has no targets then.
+	Members []*Record `json:"members,omitempty"`
+
+	// The health check of the record's targets, nil if they aren't checked.
+	HealthCheck *pkg.HealthCheck `json:"healthCheck,omitempty"`
+
+	// The ownership information of the record, nil if it has none.
+	Owner *pkg.Owner `json:"owner,omitempty"`
+
+	// The TXT record holding the ownership information as found in the
+	// zone. Nil for desired records, unless they're shared by several
+	// owners whose ownership it holds.
+	OwnerRecord *Record `json:"ownerRecord,omitempty"`
+}
+
+// Update replaces an existing record with a new version of it.
+type Update struct {
+	Old *Record `json:"old"`
+	New *Record `json:"new"`
+}
+
+// Changes is the minimal set of changes bringing a zone to the desired state.
+type Changes struct {
+	Create []*Record `json:"create,omitempty"`
+	Update []*Update `json:"update,omitempty"`
+	Delete []*Record `json:"delete,omitempty"`
+}
+
+// Empty returns true if there is nothing to change.
+func (c *Changes) Empty() bool {
+	return len(c.Create) == 0 && len(c.Update) == 0 && len(c.Delete) == 0
+}
+
+// String returns the changes in a diff like format with one line per record,
+// prefixed with "+" for records to be created, "~" for updates and "-" for
+// deletions.
+func (c *Changes) String() string {
+	if c.Empty() {
+		return "no changes"
+	}
+
+	var lines []string
+	for _, r := range c.Create {
+		lines = append(lines, "+ "+r.String())
+	}
+	for _, u := range c.Update {
+		lines = append(lines, fmt.Sprintf("~ %s -> %s", u.Old, u.New.describeValue()))
+	}
+	for _, r := range c.Delete {
+		lines = append(lines, "- "+r.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// String returns the name, set identifier, type and targets of the record.
+func (r *Record) String() string {
+	if r.SetIdentifier != "" {
+		return fmt.Sprintf("%s [%s] %s", r.Name, r.SetIdentifier, r.describeValue())
+	}
+	return r.Name + " " + r.describeValue()
+}
+
+func (r *Record) describeValue() string {
+	if r.Type == "" {
+		return "(ownership record only)"
+	}
+	value := r.Type + " " + strings.Join(r.Targets, ",")
+	if len(r.Members) > 0 {
+		value = r.Type + " " + strings.Join(describeMembers(r.Members), "; ")
+	}
+	var settings []string
+	if r.Routing != nil {
+		settings = append(settings, r.Routing.String())
+	}
+	if r.HealthCheck != nil {
+		settings = append(settings, "health check "+r.HealthCheck.String())
+	}
+	if len(settings) > 0 {
+		value += " (" + strings.Join(settings, ", ") + ")"
+	}
+	return value
+}
+
+// describeMembers returns the sorted routing policies and targets of the
+// members, e.g. "weighted 10: 1.2.3.4,5.6.7.8".
+func describeMembers(members []*Record) []string {
+	described := make([]string, 0, len(members))
+	for _, m := range members {
+		policy := "<none>"
+		if m.Routing != nil {
+			policy = m.Routing.String()
+		}
+		described = append(described, policy+": "+strings.Join(normalizeTargets(m.Targets), ","))
+	}
+	sort.Strings(described)
+	return described
+}
+
+// key identifies the record within a zone by its name and set identifier.
+func (r *Record) key() string {
+	return pkg.SanitizeDNSName(r.Name) + " " + r.SetIdentifier
+}
+
+// Calculate returns the changes needed to get from the current to the desired
+// records of a zone. Records are matched by name and set identifier and only
+// those for which owned returns true are updated or deleted. If several
+// desired records share a name, the current record is kept if it matches any
+// of them, otherwise the first one wins.
+func Calculate(desired, current []*Record, owned func(*Record) bool) *Changes {
+	changes := &Changes{}
+
+	desiredByKey := map[string][]*Record{}
+	var keys []string
+	for _, r := range desired {
+		key := r.key()
+		if _, exists := desiredByKey[key]; !exists {
+			keys = append(keys, key)
+		}
+		desiredByKey[key] = append(desiredByKey[key], r)
+	}
+
+	currentByKey := map[string]*Record{}
+	for _, r := range current {
+		currentByKey[r.key()] = r
+	}
+
+	for _, key := range keys {
+		candidates := desiredByKey[key]
+
+		existing, exists := currentByKey[key]
+		if !exists {
+			changes.Create = append(changes.Create, candidates[0])
+			continue
+		}
+
+		if !owned(existing) {
+			log.Warnf("Skipping record %s: owned by: %s", existing.Name, describeOwner(existing.Owner))
+			continue
+		}
+
+		if !anyEqual(existing, candidates) {
+			changes.Update = append(changes.Update, &Update{Old: existing, New: candidates[0]})
+		}
+	}
+
+	for _, r := range current {
+		if _, exists := desiredByKey[r.key()]; !exists && owned(r) {
+			changes.Delete = append(changes.Delete, r)
+		}
+	}
+
+	return changes
+}
+
+// Equal returns true if both records are of the same type, have the same
+// routing policy, health check and members and point to the same set of
+// targets.
+func (r *Record) Equal(other *Record) bool {
+	if r.Type != other.Type || !r.Routing.Equal(other.Routing) || !r.HealthCheck.Equal(other.HealthCheck) || len(r.Targets) != len(other.Targets) {
+		return false
+	}
+
+	if len(r.Members) != len(other.Members) || strings.Join(describeMembers(r.Members), "\n") != strings.Join(describeMembers(other.Members), "\n") {
+		return false
+	}
+
+	x, y := normalizeTargets(r.Targets), normalizeTargets(other.Targets)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func anyEqual(existing *Record, candidates []*Record) bool {
+	for _, c := range candidates {
+		if existing.Equal(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeTargets(targets []string) []string {
+	normalized := make([]string, 0, len(targets))
+	for _, t := range targets {
+		normalized = append(normalized, pkg.SanitizeDNSName(strings.ToLower(t)))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func describeOwner(owner *pkg.Owner) string {
+	if owner == nil {
+		return "<none>"
+	}
+	return owner.String()
+}
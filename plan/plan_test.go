@@ -0,0 +1,176 @@
+package plan
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zalando-incubator/mate/pkg"
+)
+
+func owned(r *Record) bool {
+	return r.Owner != nil && r.Owner.GroupID == "test"
+}
+
+func record(name, typ string, targets ...string) *Record {
+	return &Record{Name: name, Type: typ, Targets: targets}
+}
+
+func ownedRecord(name, typ string, targets ...string) *Record {
+	r := record(name, typ, targets...)
+	r.Owner = &pkg.Owner{GroupID: "test"}
+	return r
+}
+
+func names(records []*Record) map[string]bool {
+	result := map[string]bool{}
+	for _, r := range records {
+		result[r.Name] = true
+	}
+	return result
+}
+
+func TestCalculate(t *testing.T) {
+	desired := []*Record{
+		record("new.example.org.", "A", "1.2.3.4"),
+		record("same.example.org", "A", "elb.amazonaws.com"),
+		record("fighting.example.org.", "A", "new.elb"),
+		record("fighting.example.org.", "A", "old.elb"),
+		record("changed.example.org.", "A", "8.8.8.8", "8.8.4.4"),
+		record("foreign.example.org.", "A", "1.1.1.1"),
+		record("orphan.example.org.", "A", "2.2.2.2"),
+	}
+	current := []*Record{
+		ownedRecord("same.example.org.", "A", "ELB.amazonaws.com."),
+		ownedRecord("fighting.example.org.", "A", "old.elb."),
+		ownedRecord("changed.example.org.", "A", "8.8.8.8"),
+		record("foreign.example.org.", "A", "9.9.9.9"),
+		ownedRecord("orphan.example.org.", ""),
+		ownedRecord("gone.example.org.", "A", "3.3.3.3"),
+		record("unowned.example.org.", "CNAME", "example.com."),
+	}
+
+	changes := Calculate(desired, current, owned)
+
+	if len(changes.Create) != 1 || changes.Create[0].Name != "new.example.org." {
+		t.Errorf("expected new.example.org. to be created, got %v", changes.Create)
+	}
+
+	if len(changes.Update) != 2 {
+		t.Fatalf("expected two updates, got %d", len(changes.Update))
+	}
+	for _, u := range changes.Update {
+		switch u.Old.Name {
+		case "changed.example.org.":
+			if len(u.New.Targets) != 2 {
+				t.Errorf("expected changed.example.org. to be updated with both targets, got %v", u.New.Targets)
+			}
+		case "orphan.example.org.":
+			if u.New.Type != "A" {
+				t.Errorf("expected orphan.example.org. to get an A record, got %q", u.New.Type)
+			}
+		default:
+			t.Errorf("unexpected update of %s", u.Old.Name)
+		}
+	}
+
+	deleted := names(changes.Delete)
+	if len(deleted) != 1 || !deleted["gone.example.org."] {
+		t.Errorf("expected only gone.example.org. to be deleted, got %v", deleted)
+	}
+}
+
+func TestCalculateNoChanges(t *testing.T) {
+	desired := []*Record{record("foo.example.org.", "A", "1.2.3.4", "5.6.7.8")}
+	current := []*Record{ownedRecord("foo.example.org.", "A", "5.6.7.8", "1.2.3.4")}
+
+	if changes := Calculate(desired, current, owned); !changes.Empty() {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestCalculateSetIdentifiers(t *testing.T) {
+	weighted := func(r *Record, setID string, weight int64) *Record {
+		r.SetIdentifier = setID
+		r.Routing = &pkg.RoutingPolicy{Type: pkg.WeightedRouting, Weight: weight}
+		return r
+	}
+
+	desired := []*Record{
+		weighted(record("foo.example.org.", "A", "1.2.3.4"), "cluster-a", 20),
+		weighted(record("bar.example.org.", "A", "1.2.3.4"), "cluster-a", 10),
+	}
+	current := []*Record{
+		weighted(record("foo.example.org.", "A", "5.6.7.8"), "cluster-b", 10),
+		weighted(ownedRecord("bar.example.org.", "A", "1.2.3.4"), "cluster-a", 20),
+		ownedRecord("bar.example.org.", "A", "1.2.3.4"),
+	}
+
+	changes := Calculate(desired, current, owned)
+
+	if len(changes.Create) != 1 || changes.Create[0].Name != "foo.example.org." || changes.Create[0].SetIdentifier != "cluster-a" {
+		t.Errorf("expected the member of cluster-a to be created next to cluster-b, got %v", changes.Create)
+	}
+	if len(changes.Update) != 1 || changes.Update[0].New.Routing.Weight != 10 {
+		t.Errorf("expected the weight of bar.example.org. to be updated, got %v", changes.Update)
+	}
+	if len(changes.Delete) != 1 || changes.Delete[0].SetIdentifier != "" {
+		t.Errorf("expected the simple record of bar.example.org. to be deleted, got %v", changes.Delete)
+	}
+}
+
+func TestRecordEqual(t *testing.T) {
+	checked := func(r *Record, path string) *Record {
+		r.HealthCheck = &pkg.HealthCheck{Type: pkg.HTTPHealthCheck, Path: path, Port: 80, Interval: 30}
+		return r
+	}
+	weighted := func(r *Record, weights ...int64) *Record {
+		for i, weight := range weights {
+			r.Members = append(r.Members, &Record{
+				Targets: []string{fmt.Sprintf("1.2.3.%d", i)},
+				Routing: &pkg.RoutingPolicy{Type: pkg.WeightedRouting, Weight: weight},
+			})
+		}
+		return r
+	}
+
+	for _, test := range []struct {
+		x, y  *Record
+		equal bool
+	}{
+		{record("a", "A", "1.2.3.4"), record("a", "A", "1.2.3.4"), true},
+		{record("a", "A", "foo.elb"), record("a", "A", "FOO.elb."), true},
+		{record("a", "A", "foo.elb"), record("a", "CNAME", "foo.elb"), false},
+		{record("a", "A", "1.2.3.4"), record("a", "A", "1.2.3.4", "1.2.3.5"), false},
+		{record("a", ""), record("a", "A"), false},
+		{checked(record("a", "A", "1.2.3.4"), "/"), checked(record("a", "A", "1.2.3.4"), "/"), true},
+		{checked(record("a", "A", "1.2.3.4"), "/"), checked(record("a", "A", "1.2.3.4"), "/healthz"), false},
+		{checked(record("a", "A", "1.2.3.4"), "/"), record("a", "A", "1.2.3.4"), false},
+		{weighted(record("a", "A"), 10, 20), weighted(record("a", "A"), 10, 20), true},
+		{weighted(record("a", "A"), 10, 20), weighted(record("a", "A"), 10, 30), false},
+		{weighted(record("a", "A"), 10, 20), weighted(record("a", "A"), 10), false},
+		{weighted(record("a", "A"), 10), record("a", "A"), false},
+	} {
+		if equal := test.x.Equal(test.y); equal != test.equal {
+			t.Errorf("%v.Equal(%v) => %t, want %t", test.x, test.y, equal, test.equal)
+		}
+	}
+}
+
+func TestChangesString(t *testing.T) {
+	changes := &Changes{
+		Create: []*Record{record("foo.example.org.", "A", "1.2.3.4")},
+		Update: []*Update{{Old: record("bar.example.org.", "A", "1.2.3.4"), New: record("bar.example.org.", "A", "5.6.7.8", "8.8.8.8")}},
+		Delete: []*Record{ownedRecord("qux.example.org.", "")},
+	}
+
+	expected := "+ foo.example.org. A 1.2.3.4\n" +
+		"~ bar.example.org. A 1.2.3.4 -> A 5.6.7.8,8.8.8.8\n" +
+		"- qux.example.org. (ownership record only)"
+	if s := changes.String(); s != expected {
+		t.Errorf("String() =>\n%s\nwant:\n%s", s, expected)
+	}
+
+	if s := (&Changes{}).String(); s != "no changes" {
+		t.Errorf("String() of empty changes => %q", s)
+	}
+}
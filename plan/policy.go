@@ -0,0 +1,43 @@
+package plan
+
+import log "github.com/Sirupsen/logrus"
+
+// Policy restricts the kind of changes applied to a zone. The zero value
+// behaves like SyncPolicy.
+type Policy string
+
+const (
+	// SyncPolicy allows records to be created, updated and deleted.
+	SyncPolicy Policy = "sync"
+
+	// UpsertOnlyPolicy allows records to be created and updated but never
+	// deleted.
+	UpsertOnlyPolicy Policy = "upsert-only"
+
+	// CreateOnlyPolicy only allows records to be created.
+	CreateOnlyPolicy Policy = "create-only"
+)
+
+// Apply removes the changes not allowed by the policy, logs them and returns
+// their number.
+func (p Policy) Apply(changes *Changes) int {
+	suppressed := 0
+
+	if p == UpsertOnlyPolicy || p == CreateOnlyPolicy {
+		for _, r := range changes.Delete {
+			log.Infof("Suppressing deletion of %s due to %s policy", r, p)
+		}
+		suppressed += len(changes.Delete)
+		changes.Delete = nil
+	}
+
+	if p == CreateOnlyPolicy {
+		for _, u := range changes.Update {
+			log.Infof("Suppressing update of %s due to %s policy", u.Old, p)
+		}
+		suppressed += len(changes.Update)
+		changes.Update = nil
+	}
+
+	return suppressed
+}
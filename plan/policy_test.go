@@ -0,0 +1,29 @@
+package plan
+
+import "testing"
+
+func TestPolicyApply(t *testing.T) {
+	for _, test := range []struct {
+		policy     Policy
+		updates    int
+		deletions  int
+		suppressed int
+	}{
+		{"", 1, 2, 0},
+		{SyncPolicy, 1, 2, 0},
+		{UpsertOnlyPolicy, 1, 0, 2},
+		{CreateOnlyPolicy, 0, 0, 3},
+	} {
+		changes := &Changes{
+			Create: []*Record{record("new.example.org.", "A", "1.2.3.4")},
+			Update: []*Update{{Old: ownedRecord("foo.example.org.", "A", "1.2.3.4"), New: record("foo.example.org.", "A", "5.6.7.8")}},
+			Delete: []*Record{ownedRecord("bar.example.org.", "A", "1.2.3.4"), ownedRecord("qux.example.org.", "")},
+		}
+
+		suppressed := test.policy.Apply(changes)
+
+		if suppressed != test.suppressed || len(changes.Create) != 1 || len(changes.Update) != test.updates || len(changes.Delete) != test.deletions {
+			t.Errorf("%q.Apply() => %d suppressed, changes:\n%s", test.policy, suppressed, changes)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/zalando-incubator/mate/pkg"
+)
+
+// Plan holds the changes computed for a set of zones, so that they can be
+// reviewed and applied later on.
+type Plan struct {
+	Zones []*Zone `json:"zones"`
+}
+
+// Zone holds the changes computed for a single zone along with the
+// fingerprint of the records they were computed against.
+type Zone struct {
+	// The provider specific identifier of the zone.
+	ID string `json:"id"`
+
+	// The DNS name of the zone.
+	Name string `json:"name"`
+
+	// The fingerprint of the zone's records at the time the changes were
+	// computed. See Fingerprint.
+	Fingerprint string `json:"fingerprint"`
+
+	// The number of records owned in the zone at the time the changes were
+	// computed.
+	Owned int `json:"owned"`
+
+	// The number of changes left out due to the policy. See Policy.Apply.
+	Suppressed int `json:"suppressed,omitempty"`
+
+	Changes *Changes `json:"changes"`
+}
+
+// NewZone calculates the changes for a zone and records its current state.
+// See Calculate.
+func NewZone(id, name string, desired, current []*Record, owned func(*Record) bool) *Zone {
+	zone := &Zone{
+		ID:          id,
+		Name:        name,
+		Fingerprint: Fingerprint(current),
+		Changes:     Calculate(desired, current, owned),
+	}
+	for _, r := range current {
+		if owned(r) {
+			zone.Owned++
+		}
+	}
+	return zone
+}
+
+// Empty returns true if none of the zones has anything to change.
+func (p *Plan) Empty() bool {
+	for _, z := range p.Zones {
+		if !z.Changes.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the changes of all zones that have something to change.
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "no changes"
+	}
+
+	var zones []string
+	for _, z := range p.Zones {
+		if z.Changes.Empty() {
+			continue
+		}
+		changes := strings.Replace(z.Changes.String(), "\n", "\n  ", -1)
+		if z.Suppressed > 0 {
+			changes += fmt.Sprintf("\n  (%d changes suppressed by policy)", z.Suppressed)
+		}
+		zones = append(zones, fmt.Sprintf("zone %s (%s):\n  %s", z.Name, z.ID, changes))
+	}
+	return strings.Join(zones, "\n")
+}
+
+// Exclude removes all changes of the records with the given names from the
+// plan, e.g. of names whose desired records are unknown, and returns the
+// number of removed changes.
+func (p *Plan) Exclude(names ...string) int {
+	excluded := make(map[string]bool, len(names))
+	for _, name := range names {
+		excluded[pkg.SanitizeDNSName(name)] = true
+	}
+	isExcluded := func(r *Record) bool {
+		return excluded[pkg.SanitizeDNSName(r.Name)]
+	}
+
+	removed := 0
+	for _, z := range p.Zones {
+		c := z.Changes
+		var create, remove []*Record
+		var update []*Update
+		for _, r := range c.Create {
+			if !isExcluded(r) {
+				create = append(create, r)
+			}
+		}
+		for _, u := range c.Update {
+			if !isExcluded(u.Old) {
+				update = append(update, u)
+			}
+		}
+		for _, r := range c.Delete {
+			if !isExcluded(r) {
+				remove = append(remove, r)
+			}
+		}
+		removed += len(c.Create) + len(c.Update) + len(c.Delete) - len(create) - len(update) - len(remove)
+		c.Create, c.Update, c.Delete = create, update, remove
+	}
+	return removed
+}
+
+// Save writes the plan to the given file.
+func (p *Plan) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Load reads a plan previously written by Save.
+func Load(path string) (*Plan, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plan{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("Error parsing plan %s: %v", path, err)
+	}
+
+	for _, z := range p.Zones {
+		if z.Changes == nil {
+			z.Changes = &Changes{}
+		}
+	}
+
+	return p, nil
+}
+
+// Fingerprint returns a hash of the current records of a zone. It changes
+// whenever a record or an ownership record is added, removed or modified.
+func Fingerprint(current []*Record) string {
+	lines := make([]string, 0, len(current))
+	for _, r := range current {
+		line := fmt.Sprintf("%s %d %s", r.String(), r.TTL, formatAttributes(r.Attributes))
+		if r.OwnerRecord != nil {
+			line += " " + strings.Join(r.OwnerRecord.Targets, ",")
+		}
+		lines = append(lines, line)
+	}
+	sort.Strings(lines)
+
+	hash := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(hash[:])
+}
+
+func formatAttributes(attributes map[string]string) string {
+	pairs := make([]string, 0, len(attributes))
+	for k, v := range attributes {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,80 @@
+package plan
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPlanSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mate-plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := &Plan{Zones: []*Zone{
+		{ID: "zone-1", Name: "example.org.", Fingerprint: "abc", Changes: &Changes{
+			Create: []*Record{ownedRecord("foo.example.org.", "A", "1.2.3.4")},
+		}},
+		{ID: "zone-2", Name: "example.com.", Fingerprint: "def", Changes: &Changes{}},
+	}}
+
+	path := filepath.Join(dir, "plan.json")
+	if err := p.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, p) {
+		t.Errorf("Load() => %v, want %v", loaded, p)
+	}
+}
+
+func TestPlanExclude(t *testing.T) {
+	p := &Plan{Zones: []*Zone{
+		{ID: "zone-1", Name: "example.org.", Changes: &Changes{
+			Create: []*Record{ownedRecord("foo.example.org.", "A", "1.2.3.4")},
+			Update: []*Update{{
+				Old: ownedRecord("bar.example.org.", "A", "1.2.3.4", "1.2.3.5"),
+				New: ownedRecord("bar.example.org.", "A", "1.2.3.4"),
+			}},
+			Delete: []*Record{ownedRecord("baz.example.org.", "A", "1.2.3.4")},
+		}},
+		{ID: "zone-2", Name: "example.com.", Changes: &Changes{
+			Delete: []*Record{ownedRecord("qux.example.com.", "A", "1.2.3.4")},
+		}},
+	}}
+
+	if removed := p.Exclude("bar.example.org", "baz.example.org."); removed != 2 {
+		t.Errorf("Exclude() => %d, want 2", removed)
+	}
+	if c := p.Zones[0].Changes; len(c.Create) != 1 || len(c.Update) != 0 || len(c.Delete) != 0 {
+		t.Errorf("expected only the creation of foo.example.org. to be left, got %v", c)
+	}
+	if c := p.Zones[1].Changes; len(c.Delete) != 1 {
+		t.Errorf("expected the changes of other names to be kept, got %v", c)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	current := []*Record{
+		ownedRecord("foo.example.org.", "A", "1.2.3.4"),
+		ownedRecord("bar.example.org.", "A", "5.6.7.8"),
+	}
+	reordered := []*Record{current[1], current[0]}
+
+	if Fingerprint(current) != Fingerprint(reordered) {
+		t.Error("expected the fingerprint not to depend on the order of records")
+	}
+
+	changed := []*Record{current[0], ownedRecord("bar.example.org.", "A", "8.8.8.8")}
+	if Fingerprint(current) == Fingerprint(changed) {
+		t.Error("expected the fingerprint to change with the targets of a record")
+	}
+}
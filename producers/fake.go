@@ -66,7 +66,7 @@ func (a *fakeProducer) Endpoints() ([]*pkg.Endpoint, error) {
 	for i := 0; i < 10; i++ {
 		endpoint, err := a.generateEndpoint()
 		if err != nil {
-			log.Warn("[Fake] Error generating fake endpoint: %v", err)
+			log.Warnf("[Fake] Error generating fake endpoint: %v", err)
 			continue
 		}
 
@@ -157,7 +157,10 @@ func (a *kubernetesIngressProducer) convertIngressToEndpoint(ing extensions.Ingr
 	endpoints := make([]*pkg.Endpoint, 0, len(ing.Spec.Rules))
 
 	for _, rule := range ing.Spec.Rules {
-		ep := &pkg.Endpoint{}
+		ep := &pkg.Endpoint{
+			Resource:    pkg.ResourceName("ingress", ing.Namespace, ing.Name),
+			Annotations: ing.Annotations,
+		}
 
 		for _, i := range ing.Status.LoadBalancer.Ingress {
 			ep.IP = i.IP
@@ -155,7 +155,9 @@ func (a *kubernetesNodePortsProducer) convertNodePortServiceToEndpoint(svc api.S
 			}
 
 			ep := &pkg.Endpoint{
-				DNSName: svc.ObjectMeta.Annotations[annotationKey],
+				DNSName:     svc.ObjectMeta.Annotations[annotationKey],
+				Resource:    pkg.ResourceName("service", svc.Namespace, svc.Name),
+				Annotations: svc.Annotations,
 			}
 
 			if ep.DNSName == "" {
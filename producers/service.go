@@ -162,7 +162,9 @@ func validateService(svc api.Service, filter map[string]string) error {
 
 func (a *kubernetesServiceProducer) convertServiceToEndpoint(svc api.Service) (*pkg.Endpoint, error) {
 	ep := &pkg.Endpoint{
-		DNSName: svc.ObjectMeta.Annotations[annotationKey],
+		DNSName:     svc.ObjectMeta.Annotations[annotationKey],
+		Resource:    pkg.ResourceName("service", svc.Namespace, svc.Name),
+		Annotations: svc.Annotations,
 	}
 
 	if ep.DNSName == "" {